@@ -0,0 +1,20 @@
+use std::env::current_dir;
+
+use cosmwasm_schema::{export_schema, schema_for, write_api};
+
+use cw_ibc_example::msg::{ExecuteMsg, IbcExecuteMsg, InstantiateMsg, QueryMsg};
+
+fn main() {
+    write_api! {
+        instantiate: InstantiateMsg,
+        execute: ExecuteMsg,
+        query: QueryMsg,
+    }
+
+    // Packet data isn't part of the contract's API, so `write_api!`
+    // doesn't know about it.
+    let mut out_dir = current_dir().unwrap();
+    out_dir.push("schema");
+    out_dir.push("raw");
+    export_schema(&schema_for!(IbcExecuteMsg), &out_dir);
+}
@@ -2,5 +2,6 @@ use cw_storage_plus::Map;
 
 /// (channel_id) -> count. Reset on channel closure.
 pub const CONNECTION_COUNTS: Map<String, u32> = Map::new("connection_counts");
-/// (channel_id) -> timeout_count. Reset on channel closure.
+/// (channel_id) -> timeout_count. Kept on channel closure, as the
+/// packets that timed out were sent all the same.
 pub const TIMEOUT_COUNTS: Map<String, u32> = Map::new("timeout_count");
@@ -0,0 +1,56 @@
+// Package harness lets counting scenarios be written once and run
+// against any backend: simulated chains (`simtests.NewHarness`),
+// which are fast and run offline, or real chains in docker
+// (`helper.NewInterchainHarness` in the strangelove tests).
+//
+// It has no dependencies so that both test modules can use it.
+package harness
+
+import "context"
+
+// One of the two chains in a harness.
+type Side int
+
+const (
+	A Side = iota
+	B
+)
+
+func (s Side) String() string {
+	if s == A {
+		return "A"
+	}
+	return "B"
+}
+
+// The other chain.
+func (s Side) Other() Side {
+	return 1 - s
+}
+
+// Two chains, each of which can run a cw-ibc-example contract, and a
+// relayer between them.
+type Harness interface {
+	// Stores and instantiates the contract on both chains.
+	Deploy(ctx context.Context) error
+	// Opens a counter-1 channel between the contracts.
+	OpenChannel(ctx context.Context) error
+	// Executes `increment` on `side`'s contract, sending a packet
+	// to the other side.
+	Increment(ctx context.Context, side Side) error
+	// Returns once every packet sent so far has been relayed and
+	// acknowledged.
+	Relay(ctx context.Context) error
+	// The number of packets `side`'s contract has received over
+	// the channel.
+	Count(ctx context.Context, side Side) (uint32, error)
+	// The number of packets `side`'s contract sent over the
+	// channel that timed out.
+	TimeoutCount(ctx context.Context, side Side) (uint32, error)
+}
+
+// The subset of `testing.TB` scenarios use.
+type T interface {
+	Helper()
+	Fatalf(format string, args ...any)
+}
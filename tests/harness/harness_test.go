@@ -0,0 +1,109 @@
+package harness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// An in-memory counter contract on each side. If `drop` is set,
+// packets are lost instead of relayed.
+type fakeHarness struct {
+	deployed, open bool
+	drop           bool
+	pending        []Side
+	counts         [2]uint32
+}
+
+func (h *fakeHarness) Deploy(context.Context) error {
+	h.deployed = true
+	return nil
+}
+
+func (h *fakeHarness) OpenChannel(context.Context) error {
+	if !h.deployed {
+		return errors.New("not deployed")
+	}
+	h.open = true
+	return nil
+}
+
+func (h *fakeHarness) Increment(_ context.Context, side Side) error {
+	if !h.open {
+		return errors.New("no channel")
+	}
+	h.pending = append(h.pending, side)
+	return nil
+}
+
+func (h *fakeHarness) Relay(context.Context) error {
+	for _, from := range h.pending {
+		if !h.drop {
+			h.counts[from.Other()]++
+		}
+	}
+	h.pending = nil
+	return nil
+}
+
+func (h *fakeHarness) Count(_ context.Context, side Side) (uint32, error) {
+	return h.counts[side], nil
+}
+
+func (h *fakeHarness) TimeoutCount(context.Context, Side) (uint32, error) {
+	return 0, nil
+}
+
+// Records the first failure and stops the scenario.
+type fakeT struct {
+	failure string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Fatalf(format string, args ...any) {
+	t.failure = fmt.Sprintf(format, args...)
+	panic(t)
+}
+
+func run(s Scenario, h Harness) (failure string) {
+	t := &fakeT{}
+	defer func() {
+		if r := recover(); r != nil && r != t {
+			panic(r)
+		}
+		failure = t.failure
+	}()
+	s.Run(context.Background(), t, h)
+	return ""
+}
+
+func TestScenariosPass(t *testing.T) {
+	for _, s := range Scenarios {
+		if failure := run(s, &fakeHarness{}); failure != "" {
+			t.Errorf("%s: %s", s.Name, failure)
+		}
+	}
+}
+
+func TestScenariosCatchLostPackets(t *testing.T) {
+	for _, s := range Scenarios {
+		if failure := run(s, &fakeHarness{drop: true}); failure == "" {
+			t.Errorf("%s passed with a harness which loses packets", s.Name)
+		}
+	}
+	expected := "expected count 1 on B, got 0"
+	if failure := run(Scenarios[0], &fakeHarness{drop: true}); failure != expected {
+		t.Errorf("expected %q, got %q", expected, failure)
+	}
+}
+
+func TestSide(t *testing.T) {
+	if A.Other() != B || B.Other() != A {
+		t.Error("A and B should be each other's other side")
+	}
+	if A.String() != "A" || B.String() != "B" {
+		t.Error("sides should print as A and B")
+	}
+}
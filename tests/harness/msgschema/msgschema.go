@@ -0,0 +1,196 @@
+// Package msgschema checks the Go types tests use for the contract's
+// messages against the JSON schema the contract generates with
+// `cargo schema`, so that drift between `src/msg.rs` and the Go types
+// fails a test instead of surfacing as a parse error from inside the
+// wasm.
+package msgschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+	"withoutdoing.com/harness"
+)
+
+// Fails the test if `Check` finds any problems.
+func Require(t harness.T, schemaFile string, msg any) {
+	t.Helper()
+	problems, err := Check(schemaFile, msg)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if len(problems) != 0 {
+		t.Fatalf("%s does not match %s:\n%s", reflect.TypeOf(msg), schemaFile, strings.Join(problems, "\n"))
+	}
+}
+
+// Checks a Go message type against a schema file, returning the
+// problems found.
+//
+// Schemas for Rust enums have a `oneOf` with an entry for each
+// variant, which Go represents as a struct with an `omitempty`
+// pointer field per variant. For these, every variant must have a
+// field with its JSON name and every field must be a variant, and
+// the message with just one field set to its type's zero value must
+// be valid, for each field. The value of `msg` is ignored.
+//
+// For other schemas `msg` itself must be valid, so pass an example.
+func Check(schemaFile string, msg any) ([]string, error) {
+	bz, err := os.ReadFile(schemaFile)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(bz))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", schemaFile, err)
+	}
+	var doc struct {
+		Title       string            `json:"title"`
+		OneOf       []json.RawMessage `json:"oneOf"`
+		Definitions json.RawMessage   `json:"definitions"`
+	}
+	if err := json.Unmarshal(bz, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", schemaFile, err)
+	}
+	if len(doc.OneOf) == 0 {
+		return validate(schema, msg)
+	}
+
+	typ := reflect.TypeOf(msg)
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s is an enum, expected a struct with a field per variant, got %s", doc.Title, typ)
+	}
+	variants, err := variantNames(doc.OneOf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", schemaFile, err)
+	}
+	fields := jsonFields(typ)
+
+	var problems []string
+	for _, variant := range sortedKeys(variants) {
+		if _, ok := fields[variant]; !ok {
+			problems = append(problems, fmt.Sprintf("no field for the %q variant of %s", variant, doc.Title))
+		}
+	}
+	for _, name := range sortedKeys(fields) {
+		field := fields[name]
+		if _, ok := variants[name]; !ok {
+			problems = append(problems, fmt.Sprintf("field %s (%q) is not a variant of %s", field.Name, name, doc.Title))
+			continue
+		}
+		if field.Type.Kind() != reflect.Pointer {
+			problems = append(problems, fmt.Sprintf("field %s (%q) must be a pointer so that unset variants are omitted", field.Name, name))
+			continue
+		}
+		// checked against the variant's schema alone, as errors
+		// from the whole enum's `oneOf` are vague.
+		variantSchema, err := subschema(doc.OneOf[variants[name]], doc.Definitions)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", schemaFile, err)
+		}
+		v := reflect.New(typ).Elem()
+		v.FieldByIndex(field.Index).Set(reflect.New(field.Type.Elem()))
+		errs, err := validate(variantSchema, v.Interface())
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range errs {
+			problems = append(problems, fmt.Sprintf("%s: %s", field.Name, e))
+		}
+	}
+	return problems, nil
+}
+
+func validate(schema *gojsonschema.Schema, msg any) ([]string, error) {
+	bz, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(bz))
+	if err != nil {
+		return nil, err
+	}
+	var problems []string
+	for _, e := range result.Errors() {
+		problems = append(problems, fmt.Sprintf("%s: %s", bz, e))
+	}
+	return problems, nil
+}
+
+// The names of an enum's variants, mapped to their index in `oneOf`.
+// Variants with fields are objects with a single property, and
+// variants without them are strings.
+func variantNames(oneOf []json.RawMessage) (map[string]int, error) {
+	names := map[string]int{}
+	for i, raw := range oneOf {
+		var variant struct {
+			Type     string   `json:"type"`
+			Required []string `json:"required"`
+			Enum     []string `json:"enum"`
+		}
+		if err := json.Unmarshal(raw, &variant); err != nil {
+			return nil, err
+		}
+		switch {
+		case variant.Type == "object" && len(variant.Required) == 1:
+			names[variant.Required[0]] = i
+		case variant.Type == "string" && len(variant.Enum) != 0:
+			for _, name := range variant.Enum {
+				names[name] = i
+			}
+		default:
+			return nil, fmt.Errorf("can't tell the name of variant %d: %s", i, raw)
+		}
+	}
+	return names, nil
+}
+
+// A struct's fields by the names `encoding/json` gives them.
+func jsonFields(typ reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for _, field := range reflect.VisibleFields(typ) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// A variant's schema, with the enum's definitions so that its
+// references resolve.
+func subschema(variant, definitions json.RawMessage) (*gojsonschema.Schema, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(variant, &doc); err != nil {
+		return nil, err
+	}
+	if definitions != nil {
+		doc["definitions"] = definitions
+	}
+	bz, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return gojsonschema.NewSchema(gojsonschema.NewBytesLoader(bz))
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,86 @@
+package msgschema
+
+import (
+	"reflect"
+	"testing"
+)
+
+type add struct {
+	Amount uint32 `json:"amount"`
+}
+
+func TestCheckEnum(t *testing.T) {
+	type msg struct {
+		Reset *struct{} `json:"reset,omitempty"`
+		Add   *add      `json:"add,omitempty"`
+	}
+	problems, err := Check("testdata/enum.json", msg{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// `reset` is a string variant, so `{"reset":{}}` isn't valid.
+	if len(problems) != 1 {
+		t.Fatalf("expected one problem, got %q", problems)
+	}
+}
+
+func TestCheckDrift(t *testing.T) {
+	type msg struct {
+		Add      *add      `json:"add,omitempty"`
+		Subtract *add      `json:"subtract,omitempty"`
+		Hidden   *struct{} `json:"-"`
+	}
+	problems, err := Check("testdata/enum.json", msg{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`no field for the "reset" variant of Msg`,
+		`field Subtract ("subtract") is not a variant of Msg`,
+	}
+	if !reflect.DeepEqual(expected, problems) {
+		t.Fatalf("expected %q, got %q", expected, problems)
+	}
+}
+
+func TestCheckFieldTypes(t *testing.T) {
+	type wrongAdd struct {
+		Amount string `json:"amount"`
+	}
+	type msg struct {
+		Add *wrongAdd `json:"add,omitempty"`
+	}
+	problems, err := Check("testdata/enum.json", msg{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the missing reset variant, and the amount's type.
+	if len(problems) != 2 {
+		t.Fatalf("expected two problems, got %q", problems)
+	}
+}
+
+func TestCheckStruct(t *testing.T) {
+	type response struct {
+		Count uint32 `json:"count"`
+	}
+	const schema = "../../../schema/raw/response_to_get_count.json"
+	problems, err := Check(schema, response{Count: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 0 {
+		t.Fatalf("expected no problems, got %q", problems)
+	}
+
+	problems, err = Check(schema, struct {
+		Count int32 `json:"count"`
+		Extra bool  `json:"extra"`
+	}{Count: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 2 {
+		t.Fatalf("expected two problems, got %q", problems)
+	}
+}
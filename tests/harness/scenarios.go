@@ -0,0 +1,78 @@
+package harness
+
+import "context"
+
+// A scenario runs against a harness that hasn't been deployed yet
+// and fails `t` if the harness misbehaves.
+type Scenario struct {
+	Name string
+	Run  func(ctx context.Context, t T, h Harness)
+}
+
+// Every scenario, for backends to run with `t.Run(s.Name, ...)`.
+var Scenarios = []Scenario{
+	{"Counting", Counting},
+	{"Burst", Burst},
+}
+
+// Counts back and forth one packet at a time, as in
+// `TestIBCCounting`.
+func Counting(ctx context.Context, t T, h Harness) {
+	t.Helper()
+	setup(ctx, t, h)
+	for i, side := range []Side{A, B, A, B} {
+		must(t, h.Increment(ctx, side), "incrementing on %s", side)
+		must(t, h.Relay(ctx), "relaying")
+		requireCount(ctx, t, h, side.Other(), uint32(i/2+1))
+	}
+	requireTimeouts(ctx, t, h, 0, 0)
+}
+
+// Sends several packets in each direction before relaying any of
+// them.
+func Burst(ctx context.Context, t T, h Harness) {
+	t.Helper()
+	setup(ctx, t, h)
+	for _, side := range []Side{A, A, B, A, B} {
+		must(t, h.Increment(ctx, side), "incrementing on %s", side)
+	}
+	must(t, h.Relay(ctx), "relaying")
+	requireCount(ctx, t, h, B, 3)
+	requireCount(ctx, t, h, A, 2)
+	requireTimeouts(ctx, t, h, 0, 0)
+}
+
+func setup(ctx context.Context, t T, h Harness) {
+	t.Helper()
+	must(t, h.Deploy(ctx), "deploying")
+	must(t, h.OpenChannel(ctx), "opening channel")
+	requireCount(ctx, t, h, A, 0)
+	requireCount(ctx, t, h, B, 0)
+}
+
+func requireCount(ctx context.Context, t T, h Harness, side Side, expected uint32) {
+	t.Helper()
+	count, err := h.Count(ctx, side)
+	must(t, err, "querying count on %s", side)
+	if count != expected {
+		t.Fatalf("expected count %d on %s, got %d", expected, side, count)
+	}
+}
+
+func requireTimeouts(ctx context.Context, t T, h Harness, a, b uint32) {
+	t.Helper()
+	for side, expected := range map[Side]uint32{A: a, B: b} {
+		count, err := h.TimeoutCount(ctx, side)
+		must(t, err, "querying timeout count on %s", side)
+		if count != expected {
+			t.Fatalf("expected timeout count %d on %s, got %d", expected, side, count)
+		}
+	}
+}
+
+func must(t T, err error, format string, args ...any) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf(format+": %s", append(args, err)...)
+	}
+}
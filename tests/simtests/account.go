@@ -5,7 +5,6 @@ import (
 
 	"github.com/CosmWasm/wasmd/app"
 	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
-	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
@@ -22,9 +21,21 @@ type Account struct {
 }
 
 // Generates a new account on the provided chain with 100_000_000
-// tokens of the chain's bonding denom.
-func GenAccount(t *testing.T, chain *ibctesting.TestChain) Account {
-	privkey := secp256k1.GenPrivKey()
+// tokens of the chain's bonding denom. On a fixture's chains the key
+// comes from the fixture's seed (see `seed.go`).
+func GenAccount(t testing.TB, chain *ibctesting.TestChain) Account {
+	var account Account
+	_ = record(chain, func(f *Fixture) RecordedStep {
+		return RecordedStep{Account: f.chainName(chain)}
+	}, func() error {
+		account = genAccount(t, chain)
+		return nil
+	})
+	return account
+}
+
+func genAccount(t testing.TB, chain *ibctesting.TestChain) Account {
+	privkey := genPrivKey(chain)
 	pubkey := privkey.PubKey()
 	addr := sdk.AccAddress(pubkey.Address())
 
@@ -52,7 +63,17 @@ func GenAccount(t *testing.T, chain *ibctesting.TestChain) Account {
 
 // Sends some messages from an account.
 func (a *Account) Send(t *testing.T, msgs ...sdk.Msg) (*sdk.Result, error) {
+	var r *sdk.Result
+	err := recordTxOf(a.Chain, a.Address.String(), msgs, func() (err error) {
+		r, err = a.send(t, msgs...)
+		return err
+	})
+	return r, err
+}
+
+func (a *Account) send(t *testing.T, msgs ...sdk.Msg) (*sdk.Result, error) {
 	a.Chain.Coordinator.UpdateTime()
+	height, at := a.Chain.CurrentHeader.Height, a.Chain.Coordinator.CurrentTime
 
 	_, r, err := app.SignAndDeliver(
 		t,
@@ -65,6 +86,7 @@ func (a *Account) Send(t *testing.T, msgs ...sdk.Msg) (*sdk.Result, error) {
 		[]uint64{a.Acc.GetSequence()},
 		a.PrivKey,
 	)
+	recordTx(a.Chain, height, at, msgs, r, err)
 	if err != nil {
 		t.Log("goodbye")
 		return r, err
@@ -84,3 +106,10 @@ func (a *Account) Send(t *testing.T, msgs ...sdk.Msg) (*sdk.Result, error) {
 
 	return r, nil
 }
+
+// Re-reads the account's number and sequence from its chain. Needed
+// when the chain renumbers accounts, as it does when restarted from
+// genesis.
+func (a *Account) Reload() {
+	a.Acc = a.Chain.App.AccountKeeper.GetAccount(a.Chain.GetContext(), a.Address)
+}
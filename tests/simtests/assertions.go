@@ -0,0 +1,341 @@
+package simtests
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	wasmtypes "github.com/CosmWasm/wasmd/x/wasm/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	channeltypes "github.com/cosmos/ibc-go/v4/modules/core/04-channel/types"
+	"withoutdoing.com/harness"
+)
+
+// The assertions in this file fail the test with a description of
+// the state of both of the fixture's chains, so a failure on one
+// chain can be read alongside what the other chain was doing.
+
+// A contract instance on a chain, or the `Module` running in its
+// place. Contract addresses are derived from code and instance IDs,
+// so the fixture's contracts have the same address on both chains
+// and the chain is needed to tell them apart.
+type Contract struct {
+	Chain   *ibctesting.TestChain
+	Address sdk.AccAddress
+	// The module, if the chain runs one instead of the contract.
+	Module Module
+}
+
+// The fixture's contract, or module, on chain A.
+func (f *Fixture) CounterA() Contract {
+	return Contract{Chain: f.ChainA, Address: f.ContractA, Module: f.opts.modules[harness.A]}
+}
+
+// The fixture's contract, or module, on chain B.
+func (f *Fixture) CounterB() Contract {
+	return Contract{Chain: f.ChainB, Address: f.ContractB, Module: f.opts.modules[harness.B]}
+}
+
+// The port the contract, or module, is bound to.
+func (c Contract) Port() string {
+	if c.Module != nil {
+		return c.Module.PortID()
+	}
+	return c.Chain.ContractInfo(c.Address).IBCPortID
+}
+
+// The number of packets received over `channel`, and the number sent
+// over it that timed out.
+func (c Contract) Counts(channel string) (count, timeouts uint32, err error) {
+	if c.Module != nil {
+		return c.Module.Count(channel), c.Module.TimeoutCount(channel), nil
+	}
+	count, err = QueryCount(c.Chain, c.Address, QueryMsg{GetCount: &GetCount{Channel: channel}})
+	if err != nil {
+		return 0, 0, err
+	}
+	timeouts, err = QueryCount(c.Chain, c.Address, QueryMsg{GetTimeoutCount: &GetCount{Channel: channel}})
+	return count, timeouts, err
+}
+
+// Sends an increment over `channel`, from `account` if it's a
+// contract.
+func (c Contract) increment(t *testing.T, account *Account, channel string) error {
+	if c.Module != nil {
+		return c.Module.Increment(channel)
+	}
+	_, err := account.ExecuteIncrement(t, &c.Address, channel)
+	return err
+}
+
+// The contract, or module, bound to the endpoint's port, if there
+// is one.
+func counterOn(endpoint *ibctesting.Endpoint) (Contract, bool) {
+	port := endpoint.ChannelConfig.PortID
+	if isContractPort(port) {
+		address, err := contractFromPort(port)
+		return Contract{Chain: endpoint.Chain, Address: address}, err == nil
+	}
+	if module := moduleOn(endpoint.Chain); module != nil && module.PortID() == port {
+		return Contract{Chain: endpoint.Chain, Module: module}, true
+	}
+	return Contract{}, false
+}
+
+// Requires that `contract` has received `n` packets over `channel`.
+func (f *Fixture) RequireCount(t *testing.T, contract Contract, channel string, n uint32) {
+	t.Helper()
+	f.require(t, f.checkCount(contract, channel, n, false)...)
+}
+
+// Requires that `n` packets `contract` sent over `channel` have
+// timed out.
+func (f *Fixture) RequireTimeoutCount(t *testing.T, contract Contract, channel string, n uint32) {
+	t.Helper()
+	f.require(t, f.checkCount(contract, channel, n, true)...)
+}
+
+// Requires that the endpoint's channel, and its counterparty's, are
+// open.
+func (f *Fixture) RequireChannelOpen(t *testing.T, endpoint *ibctesting.Endpoint) {
+	t.Helper()
+	f.require(t, checkChannelOpen(endpoint)...)
+}
+
+// Requires that `address` on `chain` holds exactly `coins`.
+func (f *Fixture) RequireBalance(t *testing.T, chain *ibctesting.TestChain, address sdk.AccAddress, coins sdk.Coins) {
+	t.Helper()
+	if got := chain.AllBalances(address); !got.IsEqual(coins) {
+		f.require(t, fmt.Sprintf("%s on %s: expected balance %s, got %s", address, chain.ChainID, coins, got))
+	}
+}
+
+// Requires that no packets are waiting to be relayed over any of the
+// paths, or the fixture's path if none are given.
+func (f *Fixture) RequireNoPendingPackets(t *testing.T, paths ...*ibctesting.Path) {
+	t.Helper()
+	var problems []string
+	for _, path := range f.paths(paths) {
+		problems = append(problems, checkNoPendingPackets(path)...)
+	}
+	f.require(t, problems...)
+}
+
+// Requires that every packet sent over any of the paths, or the
+// fixture's path if none are given, has been received and
+// acknowledged or has timed out, and that the contracts' counts agree
+// with that: for each direction of each path, the receiver's count
+// plus the sender's timeout count is the number of packets sent.
+// Counts are only checked between contracts and modules, so other
+// paths like `TransferPath` are only checked for packets. On fee
+// enabled channels the fees for every packet must have been paid
+// out.
+func (f *Fixture) RequireConverged(t *testing.T, paths ...*ibctesting.Path) {
+	t.Helper()
+	var problems []string
+	for _, path := range f.paths(paths) {
+		problems = append(problems, checkNoPendingPackets(path)...)
+		for _, src := range []*ibctesting.Endpoint{path.EndpointA, path.EndpointB} {
+			problems = append(problems, checkConverged(src)...)
+		}
+	}
+	f.require(t, problems...)
+}
+
+// Describes the state of both chains: the fixture's contracts'
+// state, the channels bound to their ports, and the packets waiting
+// to be relayed.
+func (f *Fixture) Describe() string {
+	var b strings.Builder
+	for _, c := range []Contract{f.CounterA(), f.CounterB()} {
+		describeChain(&b, c)
+	}
+	return b.String()
+}
+
+func (f *Fixture) require(t *testing.T, problems ...string) {
+	t.Helper()
+	if len(problems) == 0 {
+		return
+	}
+	t.Fatalf("%s\n\n%s", strings.Join(problems, "\n"), f.Describe())
+}
+
+func (f *Fixture) paths(paths []*ibctesting.Path) []*ibctesting.Path {
+	if len(paths) == 0 {
+		return []*ibctesting.Path{f.Path}
+	}
+	return paths
+}
+
+func (f *Fixture) checkCount(contract Contract, channel string, n uint32, timeouts bool) []string {
+	count, timeoutCount, err := contract.Counts(channel)
+	if err != nil {
+		return []string{fmt.Sprintf("reading counts of %s on %s: %s", contract, contract.Chain.ChainID, err)}
+	}
+	what, got := "count", count
+	if timeouts {
+		what, got = "timeout count", timeoutCount
+	}
+	if got != n {
+		return []string{fmt.Sprintf("%s on %s: expected %s %d for %s, got %d", contract, contract.Chain.ChainID, what, n, channel, got)}
+	}
+	return nil
+}
+
+// The contract's address, or the module's type and port.
+func (c Contract) String() string {
+	if c.Module != nil {
+		return fmt.Sprintf("%T on %s", c.Module, c.Module.PortID())
+	}
+	return c.Address.String()
+}
+
+func checkChannelOpen(endpoint *ibctesting.Endpoint) []string {
+	var problems []string
+	for _, e := range []*ibctesting.Endpoint{endpoint, endpoint.Counterparty} {
+		channel, ok := e.Chain.App.IBCKeeper.ChannelKeeper.GetChannel(e.Chain.GetContext(), e.ChannelConfig.PortID, e.ChannelID)
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s on %s: channel does not exist", e.ChannelID, e.Chain.ChainID))
+		case channel.State != channeltypes.OPEN:
+			problems = append(problems, fmt.Sprintf("%s on %s: expected channel to be OPEN, got %s", e.ChannelID, e.Chain.ChainID, channel.State))
+		}
+	}
+	return problems
+}
+
+func checkNoPendingPackets(path *ibctesting.Path) []string {
+	var problems []string
+	fromA, fromB, err := PendingPackets(path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	for _, p := range append(fromA, fromB...) {
+		problems = append(problems, fmt.Sprintf("packet %d from %s to %s is pending", p.Sequence, p.SourceChannel, p.DestinationChannel))
+	}
+	return problems
+}
+
+// Checks that packets sent from `src` have all been dealt with.
+func checkConverged(src *ibctesting.Endpoint) []string {
+	dst := src.Counterparty
+	ctx := src.Chain.GetContext()
+	keeper := src.Chain.App.IBCKeeper.ChannelKeeper
+
+	var problems []string
+	if commitments := keeper.GetAllPacketCommitmentsAtChannel(ctx, src.ChannelConfig.PortID, src.ChannelID); len(commitments) != 0 {
+		problems = append(problems, fmt.Sprintf("%s on %s: %d packets have not been acknowledged or timed out", src.ChannelID, src.Chain.ChainID, len(commitments)))
+	}
+
+	if fees := EscrowedFees(src); len(fees) != 0 {
+		problems = append(problems, fmt.Sprintf("%s on %s: fees for %d packets have not been paid out", src.ChannelID, src.Chain.ChainID, len(fees)))
+	}
+
+	next, ok := keeper.GetNextSequenceSend(ctx, src.ChannelConfig.PortID, src.ChannelID)
+	if !ok {
+		return append(problems, fmt.Sprintf("%s on %s: channel does not exist", src.ChannelID, src.Chain.ChainID))
+	}
+	sent := uint32(next - 1)
+	// only contracts and modules keep counts.
+	srcCounter, ok := counterOn(src)
+	if !ok {
+		return problems
+	}
+	dstCounter, ok := counterOn(dst)
+	if !ok {
+		return problems
+	}
+	_, timedOut, err := srcCounter.Counts(src.ChannelID)
+	if err != nil {
+		return append(problems, err.Error())
+	}
+	received, _, err := dstCounter.Counts(dst.ChannelID)
+	if err != nil {
+		return append(problems, err.Error())
+	}
+	if received+timedOut != sent {
+		problems = append(problems, fmt.Sprintf(
+			"%s on %s sent %d packets, but %s on %s counted %d and %d timed out",
+			src.ChannelID, src.Chain.ChainID, sent, dst.ChannelID, dst.Chain.ChainID, received, timedOut,
+		))
+	}
+	return problems
+}
+
+func describeChain(b *strings.Builder, c Contract) {
+	chain := c.Chain
+	ctx := chain.GetContext()
+	fmt.Fprintf(b, "%s at height %d:\n", chain.ChainID, chain.CurrentHeader.Height)
+
+	var info *wasmtypes.ContractInfo
+	if c.Address != nil {
+		info = chain.App.WasmKeeper.GetContractInfo(ctx, c.Address)
+	}
+	switch {
+	case c.Module != nil:
+		counts := struct {
+			Counts        map[string]uint32 `json:"counts"`
+			TimeoutCounts map[string]uint32 `json:"timeout_counts"`
+		}{map[string]uint32{}, map[string]uint32{}}
+		for _, channel := range chain.App.IBCKeeper.ChannelKeeper.GetAllChannels(ctx) {
+			if channel.PortId == c.Module.PortID() {
+				counts.Counts[channel.ChannelId] = c.Module.Count(channel.ChannelId)
+				counts.TimeoutCounts[channel.ChannelId] = c.Module.TimeoutCount(channel.ChannelId)
+			}
+		}
+		bz, _ := json.Marshal(counts)
+		fmt.Fprintf(b, "  %s: %s\n", c, bz)
+		describeChannels(b, chain, c.Module.PortID())
+	case info == nil:
+		fmt.Fprintln(b, "  contract not deployed")
+	default:
+		if state, err := readContractState(chain, c.Address); err != nil {
+			fmt.Fprintf(b, "  contract %s: %s\n", c.Address, err)
+		} else {
+			bz, _ := json.Marshal(state)
+			fmt.Fprintf(b, "  contract %s: %s\n", c.Address, bz)
+		}
+		describeChannels(b, chain, info.IBCPortID)
+	}
+
+	for _, p := range chain.PendingSendPackets {
+		fmt.Fprintf(b, "  pending packet %d from %s to %s\n", p.Sequence, p.SourceChannel, p.DestinationChannel)
+	}
+}
+
+// Describes the channels bound to `port`.
+func describeChannels(b *strings.Builder, chain *ibctesting.TestChain, port string) {
+	ctx := chain.GetContext()
+	channels := chain.App.IBCKeeper.ChannelKeeper.GetAllChannels(ctx)
+	sort.Slice(channels, func(i, j int) bool {
+		return channelNumber(channels[i].ChannelId) < channelNumber(channels[j].ChannelId)
+	})
+	for _, channel := range channels {
+		if channel.PortId != port {
+			continue
+		}
+		next, _ := chain.App.IBCKeeper.ChannelKeeper.GetNextSequenceSend(ctx, channel.PortId, channel.ChannelId)
+		fmt.Fprintf(b, "  %s %s -> %s, %d sent", channel.ChannelId, channel.State, channel.Counterparty.ChannelId, next-1)
+		if chain.App.IBCFeeKeeper.IsFeeEnabled(ctx, channel.PortId, channel.ChannelId) {
+			fees := chain.App.IBCFeeKeeper.GetIdentifiedPacketFeesForChannel(ctx, channel.PortId, channel.ChannelId)
+			fmt.Fprintf(b, ", fee enabled with fees escrowed for %d packets", len(fees))
+		}
+		fmt.Fprintln(b)
+	}
+}
+
+func isContractPort(port string) bool {
+	return strings.HasPrefix(port, "wasm.")
+}
+
+// The address of the contract bound to a wasm port.
+func contractFromPort(port string) (sdk.AccAddress, error) {
+	if !isContractPort(port) {
+		return nil, fmt.Errorf("%s is not a wasm contract's port", port)
+	}
+	return sdk.AccAddressFromBech32(strings.TrimPrefix(port, "wasm."))
+}
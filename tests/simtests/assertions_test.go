@@ -0,0 +1,67 @@
+package simtests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertions(t *testing.T) {
+	f := SetupFixture(t)
+	a, b := f.Path.EndpointA, f.Path.EndpointB
+
+	f.RequireChannelOpen(t, a)
+	f.RequireConverged(t)
+
+	_, err := f.A.ExecuteIncrement(t, &f.ContractA, a.ChannelID)
+	require.NoError(t, err)
+	_, err = f.B.ExecuteIncrement(t, &f.ContractB, b.ChannelID)
+	require.NoError(t, err)
+	require.Len(t, checkNoPendingPackets(f.Path), 2)
+	require.Len(t, checkConverged(a), 2, "unacknowledged and uncounted")
+
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+	f.RequireNoPendingPackets(t)
+	f.RequireCount(t, f.CounterB(), b.ChannelID, 1)
+	f.RequireCount(t, f.CounterA(), a.ChannelID, 1)
+	f.RequireConverged(t)
+
+	_, err = f.A.ExecuteIncrement(t, &f.ContractA, a.ChannelID)
+	require.NoError(t, err)
+	f.Coordinator.IncrementTimeBy(DefaultTimeout + time.Minute)
+	require.NoError(t, TimeoutPendingPackets(f.Path))
+	f.RequireTimeoutCount(t, f.CounterA(), a.ChannelID, 1)
+	f.RequireConverged(t)
+
+	require.Equal(t, []string{
+		f.ContractB.String() + " on testchain1: expected count 3 for channel-0, got 1",
+	}, f.checkCount(f.CounterB(), b.ChannelID, 3, false))
+
+	require.NoError(t, CloseChannel(f.Path))
+	require.Equal(t, []string{
+		"channel-0 on testchain0: expected channel to be OPEN, got STATE_CLOSED",
+		"channel-0 on testchain1: expected channel to be OPEN, got STATE_CLOSED",
+	}, checkChannelOpen(a))
+
+	description := f.Describe()
+	require.Contains(t, description, "testchain0 at height")
+	require.Contains(t, description, "testchain1 at height")
+	require.Contains(t, description, "channel-0 STATE_CLOSED -> channel-0, 2 sent")
+	require.Contains(t, description, `"timeout_counts":{"channel-0":1}`)
+}
+
+func TestDescribeBeforeDeploy(t *testing.T) {
+	description := NewHarness(t).Fixture.Describe()
+	require.Contains(t, description, "testchain0 at height")
+	require.Contains(t, description, "testchain1 at height")
+	require.Contains(t, description, "contract not deployed")
+}
+
+func TestConvergedMissingChannel(t *testing.T) {
+	f := NewHarness(t).Fixture
+	path := ibctesting.NewPath(f.ChainA, f.ChainB)
+	path.EndpointA.ChannelID, path.EndpointB.ChannelID = "channel-7", "channel-7"
+	require.Equal(t, []string{"channel-7 on testchain0: channel does not exist"}, checkConverged(path.EndpointA))
+}
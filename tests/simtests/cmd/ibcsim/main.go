@@ -0,0 +1,251 @@
+// Runs the counter contract on two in-process simulated chains, with
+// no docker, relayer or junod needed.
+//
+//	go run ./cmd/ibcsim testdata/scenarios/counting.yaml
+//	go run ./cmd/ibcsim
+//
+// Given a scenario file (see `simtests.Scenario`) its steps are run
+// in order. Otherwise steps are read from stdin, one per line, in the
+// same YAML as a scenario file's steps:
+//
+//	> deploy: [a, b]
+//	> open_channel: main
+//	> increment: {on: a, channel: main}
+//	> relay: main
+//
+// After each step the counts on every channel, the packets waiting to
+// be relayed, and any acknowledgements written or packets timed out
+// are printed. With `-grpc` the chains' query services are served on
+// localhost for as long as the session lasts. The chains' keys and
+// start time come from `-simtests.seed` if it's set (see
+// `simtests.Seed`).
+//
+// Every session is recorded to `<timelines>/ibcsim.session.json`.
+// `-replay` runs a recording's steps again, from ibcsim or from a
+// test (see `simtests.Recording`), and stops at the first one that
+// does something different than it did when recorded:
+//
+//	go run ./cmd/ibcsim -replay TestRandomSession.session.json -wasm other.wasm
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"withoutdoing.com/harness"
+	simtests "withoutdoing.com/m/v2"
+)
+
+// The name of the session's "test", which names its timeline files.
+const sessionName = "ibcsim"
+
+const help = `Enter a step per line, for example:
+  deploy: [a, b]
+  open_channel: main
+  increment: {on: a, channel: main}
+  relay: main
+  advance_time: 3m
+  timeout: main
+  close: main
+  expect_count: {on: b, channel: main, count: 1}
+Other commands:
+  state   print the contracts' state, channels and pending packets
+  help    print this message
+  quit    end the session`
+
+func main() {
+	// registers the testing flags, which the simulated chains read.
+	testing.Init()
+	wasm := flag.String("wasm", simtests.WasmFile, "the compiled contract")
+	fees := flag.Bool("fees", false, "open channels with the ICS-29 fee middleware enabled")
+	serve := flag.Bool("grpc", false, "serve each chain's gRPC queries on a localhost port")
+	timelines := flag.String("timelines", "timelines", "where to write the session's timeline, sequence diagram and recording")
+	replay := flag.String("replay", "", "replay a session recording instead of running a scenario or reading steps")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [scenario.yaml]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 1 || (*replay != "" && flag.NArg() != 0) {
+		flag.Usage()
+		os.Exit(2)
+	}
+	simtests.WasmFile = *wasm
+	if err := flag.Set("test.outputdir", *timelines); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	session := func(t *testing.T) {
+		// cleanups run last to first, so this runs after the
+		// timeline has been written.
+		t.Cleanup(func() {
+			fmt.Printf("timeline written to %s\n", filepath.Join(*timelines, sessionName+".timeline.json"))
+			fmt.Printf("session recorded to %s\n", filepath.Join(*timelines, sessionName+".session.json"))
+		})
+		var recording simtests.Recording
+		if *replay != "" {
+			var err error
+			if recording, err = simtests.LoadRecording(*replay); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				t.FailNow()
+			}
+		}
+		var opts []simtests.FixtureOption
+		if *fees {
+			opts = append(opts, simtests.WithFees())
+		}
+		if *replay != "" {
+			// `-wasm` deploys on both chains, whatever the
+			// recording did.
+			flag.Visit(func(f *flag.Flag) {
+				if f.Name == "wasm" {
+					opts = append(opts, simtests.WithWasmFile(harness.A, *wasm), simtests.WithWasmFile(harness.B, *wasm))
+				}
+			})
+			var err error
+			if opts, err = recording.FixtureOptions(opts...); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				t.FailNow()
+			}
+		}
+		p := &printer{out: os.Stdout, session: simtests.NewSession(t, opts...)}
+		if *serve {
+			f := p.session.Fixture()
+			fmt.Printf("serving gRPC for a (%s) on %s\n", f.ChainA.ChainID, simtests.ServeGRPC(t, f.ChainA))
+			fmt.Printf("serving gRPC for b (%s) on %s\n", f.ChainB.ChainID, simtests.ServeGRPC(t, f.ChainB))
+		}
+		var err error
+		switch {
+		case *replay != "":
+			err = replaySteps(p, recording)
+		case flag.NArg() == 1:
+			err = runFile(p, flag.Arg(0))
+		default:
+			err = interact(p, os.Stdin)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			t.Fail()
+		}
+	}
+	// The simulated chains need a `*testing.T`, so the session runs
+	// as the only test of a test binary. `Main` exits when it's
+	// done.
+	testing.Main(
+		func(pat, str string) (bool, error) { return true, nil },
+		[]testing.InternalTest{{Name: sessionName, F: session}},
+		nil, nil,
+	)
+}
+
+func runFile(p *printer, path string) error {
+	s, err := simtests.LoadScenario(path)
+	if err != nil {
+		return err
+	}
+	for i, step := range s.Steps {
+		// JSON is YAML, and fits a step on a line.
+		text, err := json.Marshal(step)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(p.out, "> %s\n", text)
+		if err := p.session.Run(step); err != nil {
+			return fmt.Errorf("step %d of %q: %w\n\n%s", i+1, s.Name, err, p.session.Fixture().Describe())
+		}
+		p.printStep()
+	}
+	return nil
+}
+
+// Replays a recording's steps, printing each one like `runFile`.
+func replaySteps(p *printer, r simtests.Recording) error {
+	for i, step := range r.Steps {
+		fmt.Fprintf(p.out, "> %s\n", step.Describe())
+		if step.Error != "" {
+			fmt.Fprintf(p.out, "  failed when recorded: %s\n", step.Error)
+		}
+		if err := p.session.Replay(step); err != nil {
+			return fmt.Errorf("step %d of %q: %w\n\n%s", i+1, r.Name, err, p.session.Fixture().Describe())
+		}
+		p.printStep()
+	}
+	return nil
+}
+
+// Runs steps read from `in` until it ends or `quit` is read. Failed
+// steps are printed and the session carries on.
+func interact(p *printer, in io.Reader) error {
+	fmt.Fprintln(p.out, "ibcsim: type help for a list of steps")
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(p.out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(p.out)
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		switch line {
+		case "":
+			continue
+		case "help":
+			fmt.Fprintln(p.out, help)
+			continue
+		case "state":
+			if err := catch(func() error {
+				fmt.Fprint(p.out, p.session.Fixture().Describe())
+				return nil
+			}); err != nil {
+				fmt.Fprintf(p.out, "error: %s\n", err)
+			}
+			continue
+		case "quit", "exit":
+			return nil
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		step, err := simtests.ParseStep(line)
+		if err == nil {
+			err = catch(func() error { return p.session.Run(step) })
+		}
+		if err != nil {
+			fmt.Fprintf(p.out, "error: %s\n", err)
+			continue
+		}
+		p.printStep()
+	}
+}
+
+// Runs `f` on a goroutine of its own, returning a panic as an error
+// so that one bad command doesn't end an interactive session. A step
+// that fails the session's test with `t.FailNow`, as `require` does,
+// stops that goroutine rather than the session. What it logged is
+// printed with the test's output when the session ends.
+func catch(f func() error) (err error) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		returned := false
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			} else if !returned {
+				err = errors.New("the step failed the session, see its output when the session ends")
+			}
+		}()
+		err = f()
+		returned = true
+	}()
+	<-done
+	return err
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	simtests "withoutdoing.com/m/v2"
+)
+
+// Prints what each step of a session did.
+type printer struct {
+	out     io.Writer
+	session *simtests.Session
+	// how much of the session's timeline has been printed.
+	seen int
+}
+
+// Prints the acknowledgements written and packets timed out since
+// the last step, then every channel's counts and pending packets.
+func (p *printer) printStep() {
+	f := p.session.Fixture()
+	timeline := f.Recorder.Timeline()
+	for _, e := range timeline[p.seen:] {
+		a := e.Attributes
+		switch e.Type {
+		case "write_acknowledgement":
+			fmt.Fprintf(p.out, "  %s acked packet %s on %s: %s\n", p.chain(e.Chain), a["packet_sequence"], a["packet_dst_channel"], a["packet_ack"])
+		case "timeout_packet":
+			fmt.Fprintf(p.out, "  %s timed out packet %s on %s\n", p.chain(e.Chain), a["packet_sequence"], a["packet_src_channel"])
+		}
+	}
+	p.seen = len(timeline)
+
+	if len(p.session.Channels()) == 0 {
+		fmt.Fprintln(p.out, "  no channels are open")
+	}
+
+	for _, name := range p.session.Channels() {
+		counts := make([]string, 0, 2)
+		for _, chain := range []string{"a", "b"} {
+			count, timeouts, err := p.session.Counts(chain, name)
+			if err != nil {
+				counts = append(counts, fmt.Sprintf("%s %s", chain, err))
+				continue
+			}
+			counts = append(counts, fmt.Sprintf("%s count %d timeouts %d", chain, count, timeouts))
+		}
+		fmt.Fprintf(p.out, "  %s: %s\n", name, strings.Join(counts, ", "))
+
+		path, _ := p.session.Path(name)
+		fromA, fromB, err := simtests.PendingPackets(path)
+		if err != nil {
+			fmt.Fprintf(p.out, "    pending packets: %s\n", err)
+			continue
+		}
+		for _, pending := range append(fromA, fromB...) {
+			fmt.Fprintf(p.out, "    pending packet %d %s -> %s, times out at %s\n",
+				pending.Sequence, pending.SourceChannel, pending.DestinationChannel,
+				pending.TimeoutTimestamp.UTC().Format(time.RFC3339))
+		}
+	}
+}
+
+// Names a chain `a` or `b`, as steps do.
+func (p *printer) chain(chainID string) string {
+	if chainID == p.session.Fixture().ChainA.ChainID {
+		return "a"
+	}
+	return "b"
+}
@@ -0,0 +1,250 @@
+package simtests
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	channeltypes "github.com/cosmos/ibc-go/v4/modules/core/04-channel/types"
+	"github.com/stretchr/testify/require"
+	"withoutdoing.com/harness"
+)
+
+// An implementation of counter-1 for `RunConformance` to check.
+// Returns the option that sets up a fresh instance of it on one side
+// of a fixture. Implementations are driven with the contract's
+// `ExecuteMsg` and `QueryMsg`, so they must understand them.
+//
+// Implementations are either compiled wasm code, or a `Module`
+// written in Go.
+type Implementation func(side harness.Side) FixtureOption
+
+// The contract compiled to `file`, for example `WasmFile`.
+func WasmImplementation(file string) Implementation {
+	return func(side harness.Side) FixtureOption {
+		return WithWasmFile(side, file)
+	}
+}
+
+// The Go IBC module made by `newModule`, which is called once for
+// each fixture.
+func ModuleImplementation(newModule func() Module) Implementation {
+	return func(side harness.Side) FixtureOption {
+		return WithModule(side, newModule())
+	}
+}
+
+// Checks that `impl` follows the counter-1 protocol, as the
+// cw-ibc-example contract does:
+//
+//   - it opens unordered counter-1 channels whichever side starts the
+//     handshake, and refuses ordered channels and other versions at
+//     every step of it.
+//   - packets it sends carry `{"increment":{}}`.
+//   - it counts increments received on each channel separately and
+//     acknowledges them with `{"result":...}`.
+//   - it acknowledges packets it doesn't understand with
+//     `{"error":...}` and doesn't count them.
+//   - it counts its packets that time out.
+//   - it resets a channel's count when the channel closes, but keeps
+//     its timeout count.
+//
+// The implementation runs on chain A of each subtest's fixture, and
+// a `Peer` on chain B drives it.
+func RunConformance(t *testing.T, impl Implementation) {
+	t.Run("handshake", func(t *testing.T) {
+		t.Run("opens when it starts", func(t *testing.T) {
+			f, path := setupConformance(t, impl, &Peer{})
+			require.NoError(t, OpenChannel(path))
+			f.RequireChannelOpen(t, path.EndpointA)
+			f.RequireCount(t, f.CounterA(), path.EndpointA.ChannelID, 0)
+		})
+		t.Run("opens when the counterparty starts", func(t *testing.T) {
+			f, path := setupConformance(t, impl, &Peer{})
+			require.NoError(t, OpenChannel(reversed(path)))
+			f.RequireChannelOpen(t, path.EndpointA)
+			f.RequireCount(t, f.CounterA(), path.EndpointA.ChannelID, 0)
+		})
+		t.Run("refuses to start ordered channels", func(t *testing.T) {
+			_, path := setupConformance(t, impl, &Peer{Permissive: true})
+			path.SetChannelOrdered()
+			require.Error(t, path.EndpointA.ChanOpenInit())
+		})
+		t.Run("refuses to start other versions", func(t *testing.T) {
+			_, path := setupConformance(t, impl, &Peer{Permissive: true})
+			path.EndpointA.ChannelConfig.Version = "counter-2"
+			require.Error(t, path.EndpointA.ChanOpenInit())
+		})
+		t.Run("refuses ordered channels", func(t *testing.T) {
+			_, path := setupConformance(t, impl, &Peer{Permissive: true})
+			path.SetChannelOrdered()
+			require.NoError(t, path.EndpointB.ChanOpenInit())
+			require.Error(t, path.EndpointA.ChanOpenTry())
+		})
+		t.Run("refuses other versions", func(t *testing.T) {
+			_, path := setupConformance(t, impl, &Peer{Permissive: true})
+			path.EndpointB.ChannelConfig.Version = "counter-2"
+			require.NoError(t, path.EndpointB.ChanOpenInit())
+			require.Error(t, path.EndpointA.ChanOpenTry())
+		})
+		t.Run("refuses other counterparty versions", func(t *testing.T) {
+			_, path := setupConformance(t, impl, &Peer{Permissive: true, Version: "counter-2"})
+			path.EndpointB.ChannelConfig.Version = "counter-2"
+			require.NoError(t, path.EndpointA.ChanOpenInit())
+			require.NoError(t, path.EndpointB.ChanOpenTry())
+			require.Error(t, path.EndpointA.ChanOpenAck())
+		})
+	})
+
+	t.Run("counts per channel", func(t *testing.T) {
+		driver := &Peer{}
+		f, first := setupConformance(t, impl, driver)
+		require.NoError(t, OpenChannel(first))
+		second, err := openChannelOn(first, ChannelConfig(first.EndpointA.ChannelConfig.PortID), ChannelConfig(first.EndpointB.ChannelConfig.PortID))
+		require.NoError(t, err)
+
+		for _, path := range []*ibctesting.Path{first, first, second} {
+			driverSend(t, driver, path.EndpointB.ChannelID, increment)
+		}
+		require.NoError(t, RelayAndAckPendingPackets(first))
+		require.NoError(t, RelayAndAckPendingPackets(second))
+		f.RequireCount(t, f.CounterA(), first.EndpointA.ChannelID, 2)
+		f.RequireCount(t, f.CounterA(), second.EndpointA.ChannelID, 1)
+
+		require.NoError(t, f.CounterA().increment(t, &f.A, second.EndpointA.ChannelID))
+		require.NoError(t, RelayAndAckPendingPackets(second))
+		require.Len(t, driver.Received, 1)
+		sent := driver.Received[0]
+		require.Equal(t, second.EndpointB.ChannelID, sent.DestinationChannel)
+		require.JSONEq(t, string(increment), string(sent.Data))
+		f.RequireCount(t, f.CounterB(), second.EndpointB.ChannelID, 1)
+	})
+
+	t.Run("acknowledges increments with success", func(t *testing.T) {
+		driver := &Peer{}
+		_, path := setupConformance(t, impl, driver)
+		require.NoError(t, OpenChannel(path))
+		driverSend(t, driver, path.EndpointB.ChannelID, increment)
+		require.NoError(t, RelayAndAckPendingPackets(path))
+
+		require.Len(t, driver.Acks, 1)
+		requireAckFields(t, driver.Acks[0].Acknowledgement, "result")
+	})
+
+	t.Run("acknowledges bad packets with errors", func(t *testing.T) {
+		driver := &Peer{}
+		f, path := setupConformance(t, impl, driver)
+		require.NoError(t, OpenChannel(path))
+		bad := []string{`{"decrement":{}}`, `{"increment":[]}`, `not json`}
+		for _, data := range bad {
+			driverSend(t, driver, path.EndpointB.ChannelID, []byte(data))
+		}
+		require.NoError(t, RelayAndAckPendingPackets(path))
+
+		require.Len(t, driver.Acks, len(bad))
+		for i, ack := range driver.Acks {
+			fields := requireAckFields(t, ack.Acknowledgement, "error")
+			var msg string
+			require.NoError(t, json.Unmarshal(fields["error"], &msg), "ack of %q", bad[i])
+			require.NotEmpty(t, msg, "ack of %q", bad[i])
+		}
+		f.RequireCount(t, f.CounterA(), path.EndpointA.ChannelID, 0)
+		f.RequireNoPendingPackets(t)
+	})
+
+	t.Run("counts timeouts", func(t *testing.T) {
+		f, path := setupConformance(t, impl, &Peer{})
+		require.NoError(t, OpenChannel(path))
+		for i := 0; i < 2; i++ {
+			require.NoError(t, f.CounterA().increment(t, &f.A, path.EndpointA.ChannelID))
+		}
+		timeOutPackets(t, f, path)
+
+		f.RequireTimeoutCount(t, f.CounterA(), path.EndpointA.ChannelID, 2)
+		f.RequireCount(t, f.CounterB(), path.EndpointB.ChannelID, 0)
+	})
+
+	for name, closer := range map[string]func(path *ibctesting.Path) *ibctesting.Path{
+		"resets when it closes":               func(path *ibctesting.Path) *ibctesting.Path { return path },
+		"resets when the counterparty closes": reversed,
+	} {
+		closer := closer
+		t.Run(name, func(t *testing.T) {
+			driver := &Peer{}
+			f, path := setupConformance(t, impl, driver)
+			require.NoError(t, OpenChannel(path))
+			for i := 0; i < 2; i++ {
+				driverSend(t, driver, path.EndpointB.ChannelID, increment)
+			}
+			require.NoError(t, RelayAndAckPendingPackets(path))
+			f.RequireCount(t, f.CounterA(), path.EndpointA.ChannelID, 2)
+			require.NoError(t, f.CounterA().increment(t, &f.A, path.EndpointA.ChannelID))
+			timeOutPackets(t, f, path)
+
+			require.NoError(t, CloseChannel(closer(path)))
+			require.Equal(t, channeltypes.CLOSED, path.EndpointA.GetChannel().State)
+			f.RequireCount(t, f.CounterA(), path.EndpointA.ChannelID, 0)
+			f.RequireTimeoutCount(t, f.CounterA(), path.EndpointA.ChannelID, 1)
+		})
+	}
+}
+
+// The data of an increment packet.
+var increment = mustMarshal(IbcExecuteMsg{Increment: &IbcIncrement{}})
+
+// A fixture with `impl` deployed on chain A, `driver` on chain B and
+// a connection between them, and a path for a counter-1 channel
+// between them that hasn't been opened.
+func setupConformance(t *testing.T, impl Implementation, driver *Peer) (*Fixture, *ibctesting.Path) {
+	h := NewHarness(t, impl(harness.A), WithPeer(harness.B, driver))
+	require.NoError(t, h.Deploy(context.Background()))
+	f := h.Fixture
+	path := ibctesting.NewPath(f.ChainA, f.ChainB)
+	path.EndpointA.ChannelConfig = ChannelConfig(f.CounterA().Port())
+	path.EndpointB.ChannelConfig = ChannelConfig(f.CounterB().Port())
+	f.Coordinator.SetupConnections(path)
+	f.Path = path
+	return f, path
+}
+
+// The path the other way round, so that path helpers start from
+// endpoint B.
+func reversed(path *ibctesting.Path) *ibctesting.Path {
+	return &ibctesting.Path{EndpointA: path.EndpointB, EndpointB: path.EndpointA}
+}
+
+// Has the driver send `data` over `channel`.
+func driverSend(t *testing.T, driver *Peer, channel string, data []byte) {
+	require.NoError(t, driver.SendPacket(channel, data, 0))
+}
+
+// Moves the clock past the timeout of every packet pending on chain
+// A, and times them out.
+func timeOutPackets(t *testing.T, f *Fixture, path *ibctesting.Path) {
+	var latest uint64
+	for _, packet := range f.ChainA.PendingSendPackets {
+		if packet.TimeoutTimestamp > latest {
+			latest = packet.TimeoutTimestamp
+		}
+	}
+	require.NotZero(t, latest, "packets should time out")
+	f.Coordinator.IncrementTimeBy(time.Unix(0, int64(latest)).Sub(f.Coordinator.CurrentTime) + time.Second)
+	require.NoError(t, TimeoutPendingPackets(path))
+}
+
+// Requires that `ack` is a JSON object with exactly `fields`, and
+// returns them.
+func requireAckFields(t *testing.T, ack []byte, fields ...string) map[string]json.RawMessage {
+	var decoded map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(ack, &decoded), "ack %q isn't a JSON object", ack)
+	var keys []string
+	for key := range decoded {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	require.Equal(t, fields, keys, "fields of ack %s", ack)
+	return decoded
+}
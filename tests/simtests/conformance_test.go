@@ -0,0 +1,11 @@
+package simtests
+
+import "testing"
+
+func TestContractConformance(t *testing.T) {
+	RunConformance(t, WasmImplementation(WasmFile))
+}
+
+func TestPeerConformance(t *testing.T) {
+	RunConformance(t, ModuleImplementation(func() Module { return &Peer{} }))
+}
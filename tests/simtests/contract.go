@@ -3,6 +3,7 @@ package simtests
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
 	sdk "github.com/cosmos/cosmos-sdk/types"
@@ -10,6 +11,12 @@ import (
 	sdkibctesting "github.com/cosmos/ibc-go/v4/testing"
 )
 
+// The IBC channel version the contract speaks.
+const Version = "counter-1"
+
+// The timeout of packets sent by the cw-ibc-example contract.
+const DefaultTimeout = 2 * time.Minute
+
 type InstantiateMsg struct {
 }
 
@@ -22,7 +29,8 @@ type Increment struct {
 }
 
 type QueryMsg struct {
-	GetCount *GetCount `json:"get_count,omitempty"`
+	GetCount        *GetCount `json:"get_count,omitempty"`
+	GetTimeoutCount *GetCount `json:"get_timeout_count,omitempty"`
 }
 
 type GetCount struct {
@@ -33,6 +41,14 @@ type GetCountResponse struct {
 	Count uint32 `json:"count"`
 }
 
+// The payload of packets sent over counter-1 channels.
+type IbcExecuteMsg struct {
+	Increment *IbcIncrement `json:"increment,omitempty"`
+}
+
+type IbcIncrement struct {
+}
+
 // Calls the increment method and returns the current value.
 func (a *Account) ExecuteIncrement(t *testing.T, contract *sdk.AccAddress, channel string) (uint32, error) {
 	_, err := a.Send(t, a.WasmExecute(
@@ -59,7 +75,23 @@ func (a *Account) ExecuteIncrement(t *testing.T, contract *sdk.AccAddress, chann
 	return count.Count, nil
 }
 
-func Instantiate(t *testing.T, chain *ibctesting.TestChain, codeId uint64) sdk.AccAddress {
+// Sends a query answered with a `GetCountResponse`, for example
+// `get_count` or `get_timeout_count`, to a contract.
+func QueryCount(chain *ibctesting.TestChain, contract sdk.AccAddress, msg QueryMsg) (uint32, error) {
+	query, err := json.Marshal(msg)
+	if err != nil {
+		return 0, err
+	}
+	res, err := chain.App.WasmKeeper.QuerySmart(chain.GetContext(), contract, query)
+	if err != nil {
+		return 0, err
+	}
+	var count GetCountResponse
+	err = json.Unmarshal(res, &count)
+	return count.Count, err
+}
+
+func Instantiate(t testing.TB, chain *ibctesting.TestChain, codeId uint64) sdk.AccAddress {
 	instantiate, err := json.Marshal(InstantiateMsg{})
 	if err != nil {
 		t.Fatal(err)
@@ -67,10 +99,15 @@ func Instantiate(t *testing.T, chain *ibctesting.TestChain, codeId uint64) sdk.A
 	return chain.InstantiateContract(codeId, instantiate)
 }
 
-func ChannelConfig(port string) *sdkibctesting.ChannelConfig {
+// The config for a counter-1 channel on `port`.
+func ChannelConfig(port string, opts ...FixtureOption) *sdkibctesting.ChannelConfig {
+	version := Version
+	if newFixtureOptions(opts).fees {
+		version = FeeVersion(version)
+	}
 	return &sdkibctesting.ChannelConfig{
 		PortID:  port,
-		Version: "counter-1",
+		Version: version,
 		Order:   channeltypes.UNORDERED,
 	}
 }
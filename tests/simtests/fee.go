@@ -0,0 +1,89 @@
+package simtests
+
+import (
+	"testing"
+
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	ibcfeetypes "github.com/cosmos/ibc-go/v4/modules/apps/29-fee/types"
+	channeltypes "github.com/cosmos/ibc-go/v4/modules/core/04-channel/types"
+)
+
+// Helpers for channels with the ICS-29 fee middleware enabled, see
+// `WithFees`.
+//
+// The relayer on each chain is the chain's `SenderAccount`, which
+// signs the relaying transactions in `relay.go`. Fees for packets are
+// paid to it, or to the payees registered for it, when the packets
+// are acknowledged or time out.
+
+// Wraps a channel version in the fee middleware's version.
+func FeeVersion(appVersion string) string {
+	return string(ibcfeetypes.ModuleCdc.MustMarshalJSON(&ibcfeetypes.Metadata{
+		FeeVersion: ibcfeetypes.Version,
+		AppVersion: appVersion,
+	}))
+}
+
+// The address that relays packets to and from a chain.
+func Relayer(chain *ibctesting.TestChain) sdk.AccAddress {
+	return chain.SenderAccount.GetAddress()
+}
+
+// Registers `payee` to be paid the ack and timeout fees that the
+// chain's relayer earns on the endpoint's channel.
+func RegisterPayee(endpoint *ibctesting.Endpoint, payee sdk.AccAddress) error {
+	_, err := deliver(endpoint.Chain, ibcfeetypes.NewMsgRegisterPayee(
+		endpoint.ChannelConfig.PortID, endpoint.ChannelID,
+		Relayer(endpoint.Chain).String(), payee.String(),
+	))
+	return err
+}
+
+// Registers `payee`, an address on the counterparty chain, to be paid
+// the recv fees that the chain's relayer earns by delivering packets
+// to the endpoint's channel.
+func RegisterCounterpartyPayee(endpoint *ibctesting.Endpoint, payee sdk.AccAddress) error {
+	_, err := deliver(endpoint.Chain, ibcfeetypes.NewMsgRegisterCounterpartyPayee(
+		endpoint.ChannelConfig.PortID, endpoint.ChannelID,
+		Relayer(endpoint.Chain).String(), payee.String(),
+	))
+	return err
+}
+
+// A fee of `recv`, `ack` and `timeout` of `sdk.DefaultBondDenom`,
+// which the simulated chains' accounts are funded with.
+func NewFee(recv, ack, timeout int64) ibcfeetypes.Fee {
+	coins := func(n int64) sdk.Coins {
+		return sdk.NewCoins(sdk.NewInt64Coin(sdk.DefaultBondDenom, n))
+	}
+	return ibcfeetypes.NewFee(coins(recv), coins(ack), coins(timeout))
+}
+
+// Calls the increment method, escrowing `fee` for the packet it sends
+// in the same transaction.
+func (a *Account) ExecuteIncrementWithFee(t *testing.T, contract *sdk.AccAddress, endpoint *ibctesting.Endpoint, fee ibcfeetypes.Fee) error {
+	// escrows the fee for the next packet sent over the channel, so
+	// it has to come first.
+	pay := ibcfeetypes.NewMsgPayPacketFee(fee, endpoint.ChannelConfig.PortID, endpoint.ChannelID, a.Address.String(), nil)
+	_, err := a.Send(t, pay, a.WasmExecute(contract, ExecuteMsg{
+		Increment: &Increment{Channel: endpoint.ChannelID},
+	}))
+	return err
+}
+
+// Escrows `fee` for a packet that has already been sent over the
+// endpoint's channel.
+func (a *Account) PayPacketFee(t *testing.T, endpoint *ibctesting.Endpoint, sequence uint64, fee ibcfeetypes.Fee) error {
+	packetID := channeltypes.NewPacketId(endpoint.ChannelConfig.PortID, endpoint.ChannelID, sequence)
+	_, err := a.Send(t, ibcfeetypes.NewMsgPayPacketFeeAsync(packetID, ibcfeetypes.NewPacketFee(fee, a.Address.String(), nil)))
+	return err
+}
+
+// The fees escrowed for packets sent over the endpoint's channel which
+// haven't been paid out.
+func EscrowedFees(endpoint *ibctesting.Endpoint) []ibcfeetypes.IdentifiedPacketFees {
+	return endpoint.Chain.App.IBCFeeKeeper.GetIdentifiedPacketFeesForChannel(
+		endpoint.Chain.GetContext(), endpoint.ChannelConfig.PortID, endpoint.ChannelID,
+	)
+}
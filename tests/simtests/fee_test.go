@@ -0,0 +1,92 @@
+package simtests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+)
+
+func stake(n int64) sdk.Coins {
+	return sdk.NewCoins(sdk.NewInt64Coin(sdk.DefaultBondDenom, n))
+}
+
+// An address made from the test's seed.
+func newAddress(t *testing.T) sdk.AccAddress {
+	return sdk.AccAddress(ed25519.GenPrivKeyFromSecret(secret(Rand(t))).PubKey().Address())
+}
+
+// The contract only accepts counter-1 channels, but the fee
+// middleware unwraps the version before the contract sees it.
+func TestFeeChannelOpens(t *testing.T) {
+	f := SetupFixture(t, WithFees())
+	f.RequireChannelOpen(t, f.Path.EndpointA)
+	for _, endpoint := range []*ibctesting.Endpoint{f.Path.EndpointA, f.Path.EndpointB} {
+		ctx := endpoint.Chain.GetContext()
+		channel, _ := endpoint.Chain.App.IBCKeeper.ChannelKeeper.GetChannel(ctx, endpoint.ChannelConfig.PortID, endpoint.ChannelID)
+		require.Equal(t, FeeVersion(Version), channel.Version)
+		require.True(t, endpoint.Chain.App.IBCFeeKeeper.IsFeeEnabled(ctx, endpoint.ChannelConfig.PortID, endpoint.ChannelID))
+	}
+}
+
+func TestFeeChannelRejectsOtherAppVersions(t *testing.T) {
+	f := SetupFixture(t)
+	path := ibctesting.NewPath(f.ChainA, f.ChainB)
+	path.EndpointA.ChannelConfig = ChannelConfig(f.Path.EndpointA.ChannelConfig.PortID)
+	path.EndpointB.ChannelConfig = ChannelConfig(f.Path.EndpointB.ChannelConfig.PortID)
+	path.EndpointA.ChannelConfig.Version = FeeVersion("counter-2")
+	path.EndpointB.ChannelConfig.Version = FeeVersion("counter-2")
+	path.EndpointA.ClientID, path.EndpointA.ConnectionID = f.Path.EndpointA.ClientID, f.Path.EndpointA.ConnectionID
+	path.EndpointB.ClientID, path.EndpointB.ConnectionID = f.Path.EndpointB.ClientID, f.Path.EndpointB.ConnectionID
+
+	err := OpenChannel(path)
+	require.ErrorContains(t, err, "invalid IBC channel version. Got (counter-2), expected (counter-1)")
+}
+
+func TestFeesArePaidToPayees(t *testing.T) {
+	f := SetupFixture(t, WithFees())
+	a, b := f.Path.EndpointA, f.Path.EndpointB
+
+	// the relayer on A is paid ack fees for packets from A, and the
+	// relayer on B recv fees, on A.
+	ackPayee, recvPayee := newAddress(t), newAddress(t)
+	require.NoError(t, RegisterPayee(a, ackPayee))
+	require.NoError(t, RegisterCounterpartyPayee(b, recvPayee))
+
+	fee := NewFee(10, 20, 30)
+	require.NoError(t, f.A.ExecuteIncrementWithFee(t, &f.ContractA, a, fee))
+	// fees can also be paid after the packet is sent.
+	_, err := f.A.ExecuteIncrement(t, &f.ContractA, a.ChannelID)
+	require.NoError(t, err)
+	require.NoError(t, f.A.PayPacketFee(t, a, 2, fee))
+	require.Len(t, EscrowedFees(a), 2)
+	f.RequireBalance(t, f.ChainA, f.A.Address, stake(100_000_000-2*60))
+
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+	f.RequireCount(t, f.CounterB(), b.ChannelID, 2)
+	f.RequireBalance(t, f.ChainA, recvPayee, stake(2*10))
+	f.RequireBalance(t, f.ChainA, ackPayee, stake(2*20))
+	// timeout fees are refunded.
+	f.RequireBalance(t, f.ChainA, f.A.Address, stake(100_000_000-2*30))
+	f.RequireConverged(t)
+}
+
+func TestTimeoutFeesArePaidToRelayer(t *testing.T) {
+	f := SetupFixture(t, WithFees())
+	b := f.Path.EndpointB
+
+	before := f.ChainB.AllBalances(Relayer(f.ChainB))
+	require.NoError(t, f.B.ExecuteIncrementWithFee(t, &f.ContractB, b, NewFee(10, 20, 30)))
+	f.Coordinator.IncrementTimeBy(DefaultTimeout + time.Minute)
+	require.NoError(t, TimeoutPendingPackets(f.Path))
+
+	f.RequireTimeoutCount(t, f.CounterB(), b.ChannelID, 1)
+	// no payee is registered, so the relayer is paid.
+	f.RequireBalance(t, f.ChainB, Relayer(f.ChainB), before.Add(stake(30)...))
+	// recv and ack fees are refunded.
+	f.RequireBalance(t, f.ChainB, f.B.Address, stake(100_000_000-30))
+	f.RequireConverged(t)
+}
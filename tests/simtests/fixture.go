@@ -0,0 +1,187 @@
+package simtests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	wasmkeeper "github.com/CosmWasm/wasmd/x/wasm/keeper"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+	"withoutdoing.com/harness"
+)
+
+// Where `../../justfile` places the compiled contract. `cmd/ibcsim`
+// sets this with its `-wasm` flag.
+var WasmFile = "../wasms/cw_ibc_example.wasm"
+
+// Two simulated chains, each with a cw_ibc_example contract, and a
+// counter-1 channel between the contracts. This is the setup from
+// `TestIBCCounting` packaged up for tests that don't care about how
+// it happens.
+type Fixture struct {
+	Coordinator *ibctesting.Coordinator
+	ChainA      *ibctesting.TestChain
+	ChainB      *ibctesting.TestChain
+	// Unset on a side running a `Module`.
+	ContractA sdk.AccAddress
+	ContractB sdk.AccAddress
+	Path      *ibctesting.Path
+	// An ICS-20 transfer channel on the same connection as `Path`,
+	// if the fixture was set up `WithTransferChannel`.
+	TransferPath *ibctesting.Path
+	// Records everything that happens on the chains, see
+	// `timeline.go`.
+	Recorder *Recorder
+	// Accounts on each chain to execute messages with.
+	A Account
+	B Account
+	// What the chains' keys and start time came from, see
+	// `seed.go`.
+	Seed int64
+
+	opts fixtureOptions
+	// what's been done to the chains, see `recording.go`.
+	log *actionLog
+}
+
+// Changes how `SetupFixture`, `NewHarness` and `ChannelConfig` set
+// up chains and channels.
+type FixtureOption func(*fixtureOptions)
+
+type fixtureOptions struct {
+	fees     bool
+	transfer bool
+	// wasm keeper options for chain A and chain B.
+	wasm [2][]wasmkeeper.Option
+	// modules running in place of the contract on chain A and chain
+	// B.
+	modules [2]Module
+	// contracts to deploy on chain A and chain B instead of
+	// `WasmFile`.
+	wasmFiles [2]string
+	// the seed to use instead of the test's.
+	seed *int64
+}
+
+// Opens channels with the ICS-29 fee middleware enabled by wrapping
+// their version in the fee version (see `FeeVersion`). The middleware
+// unwraps it before the contract sees it.
+func WithFees() FixtureOption {
+	return func(o *fixtureOptions) {
+		o.fees = true
+	}
+}
+
+// Passes wasm keeper options to one chain's app, to configure it
+// like a production chain, for example with
+// `wasmkeeper.WithGasRegister`, `wasmkeeper.WithQueryPlugins` or
+// `wasmkeeper.WithMessageHandlerDecorator`. `WithVM` sets the things
+// wasmd reads from the app config instead. Options for the same
+// chain add up.
+func WithWasmOptions(side harness.Side, opts ...wasmkeeper.Option) FixtureOption {
+	return func(o *fixtureOptions) {
+		o.wasm[side] = append(o.wasm[side], opts...)
+	}
+}
+
+// Deploys the contract in `file` instead of `WasmFile` on one of the
+// chains, for example another version of the contract.
+func WithWasmFile(side harness.Side, file string) FixtureOption {
+	return func(o *fixtureOptions) {
+		o.wasmFiles[side] = file
+	}
+}
+
+// Creates the chains from `seed` rather than from the test's seed,
+// for example to make the same chains as another test did.
+func WithSeed(seed int64) FixtureOption {
+	return func(o *fixtureOptions) {
+		o.seed = &seed
+	}
+}
+
+// Runs `module` in place of the contract on one of the chains. The
+// fixture's channel on that side ends on the module's port.
+func WithModule(side harness.Side, module Module) FixtureOption {
+	return func(o *fixtureOptions) {
+		o.modules[side] = module
+	}
+}
+
+// Runs `peer` in place of the contract on one of the chains, bound
+// to `peer.Port` (`PeerPort` if unset).
+func WithPeer(side harness.Side, peer *Peer) FixtureOption {
+	return WithModule(side, peer)
+}
+
+func newFixtureOptions(opts []FixtureOption) fixtureOptions {
+	var o fixtureOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Sets up a new fixture. Any failure fails the test or benchmark.
+func SetupFixture(t testing.TB, opts ...FixtureOption) *Fixture {
+	h := NewHarness(t, opts...)
+	require.NoError(t, h.Deploy(context.Background()))
+	require.NoError(t, h.OpenChannel(context.Background()))
+	return h.Fixture
+}
+
+// The `*testing.T` made for each benchmark (or other non-test) by
+// `chainT`.
+var chainTs = struct {
+	sync.Mutex
+	m map[testing.TB]*testing.T
+}{m: map[testing.TB]*testing.T{}}
+
+// The `*testing.T` to give ibctesting, and the test app, for `tb`.
+// They take nothing else, and fail it when something goes wrong. A
+// test gives its own. Anything else, like a benchmark, gets one of
+// its own that `go test` doesn't run: when `tb` finishes, its
+// temporary directories are removed and `tb` fails if it did. It
+// never prints what it logged or runs its other cleanups, so run
+// the same setup in a test to see why it failed.
+func chainT(tb testing.TB) *testing.T {
+	if t, ok := tb.(*testing.T); ok {
+		return t
+	}
+	chainTs.Lock()
+	defer chainTs.Unlock()
+	if t, ok := chainTs.m[tb]; ok {
+		return t
+	}
+	t := new(testing.T)
+	// every temporary directory of a T is in the same one.
+	dir := filepath.Dir(t.TempDir())
+	chainTs.m[tb] = t
+	tb.Cleanup(func() {
+		chainTs.Lock()
+		delete(chainTs.m, tb)
+		chainTs.Unlock()
+		if t.Failed() {
+			tb.Error("the simulated chains failed, set them up in a test to see why")
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			tb.Error(err)
+		}
+	})
+	return t
+}
+
+// The wasm keeper options `chain` was created with.
+func (f *Fixture) wasmOptions(chain *ibctesting.TestChain) []wasmkeeper.Option {
+	switch chain {
+	case f.ChainA:
+		return f.opts.wasm[harness.A]
+	case f.ChainB:
+		return f.opts.wasm[harness.B]
+	}
+	return nil
+}
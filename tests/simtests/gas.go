@@ -0,0 +1,217 @@
+package simtests
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	wasmkeeper "github.com/CosmWasm/wasmd/x/wasm/keeper"
+	wasmvmtypes "github.com/CosmWasm/wasmvm/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+// The entry points of the contract that we measure gas for.
+const (
+	GasExecuteIncrement = "execute_increment"
+	GasQueryGetCount    = "query_get_count"
+	GasChannelOpen      = "ibc_channel_open"
+	GasChannelConnect   = "ibc_channel_connect"
+	GasPacketReceive    = "ibc_packet_receive"
+	GasPacketAck        = "ibc_packet_ack"
+	GasPacketTimeout    = "ibc_packet_timeout"
+)
+
+// Plenty for any single contract call.
+const gasMeasurementLimit = 100_000_000
+
+// Gas budgets for each entry point. See `gas_budgets.json`.
+type GasBudgets struct {
+	// How far above its budget an entry point may go before the
+	// budget check fails, as a fraction of the budget.
+	Tolerance float64 `json:"tolerance"`
+	// entry point -> gas
+	Budgets map[string]uint64 `json:"budgets"`
+}
+
+func ReadGasBudgets(t *testing.T, path string) GasBudgets {
+	bz, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var budgets GasBudgets
+	require.NoError(t, json.Unmarshal(bz, &budgets))
+	return budgets
+}
+
+func WriteGasBudgets(t *testing.T, path string, budgets GasBudgets) {
+	bz, err := json.MarshalIndent(budgets, "", "  ")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, append(bz, '\n'), 0o644))
+}
+
+// Fails the test if any entry point in `measured` used more than
+// its budget plus the tolerance, or if an entry point is missing a
+// budget.
+func (b GasBudgets) Check(t *testing.T, measured map[string]uint64) {
+	for _, problem := range b.Violations(measured) {
+		t.Error(problem)
+	}
+}
+
+// Describes every entry point in `measured` that is over budget or
+// has no budget, sorted by entry point.
+func (b GasBudgets) Violations(measured map[string]uint64) []string {
+	names := make([]string, 0, len(measured))
+	for name := range measured {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, name := range names {
+		budget, ok := b.Budgets[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: no gas budget, run `go test -update` to add one", name))
+			continue
+		}
+		limit := float64(budget) * (1 + b.Tolerance)
+		if float64(measured[name]) > limit {
+			problems = append(problems, fmt.Sprintf("%s: used %d gas, over its budget of %d by more than %.0f%%", name, measured[name], budget, b.Tolerance*100))
+		}
+	}
+	return problems
+}
+
+// Measures the gas used by each of the contract's entry points on
+// chain A of the fixture. Each measurement runs in a cached context
+// so state is left untouched and measurements don't affect each
+// other. Only the contract call is metered, not the surrounding
+// transaction or IBC core handling, so numbers only move when the
+// contract (or wasmd's gas schedule) does.
+func MeasureGas(t *testing.T, f *Fixture) map[string]uint64 {
+	gas := map[string]uint64{}
+	for name, measure := range gasMeasurements(f) {
+		used, err := measureGas(f.ChainA, measure)
+		require.NoError(t, err, name)
+		gas[name] = used
+	}
+	return gas
+}
+
+// Benchmarks one of the entry points `MeasureGas` measures, and
+// reports the gas it uses as the `gas/op` metric.
+func BenchmarkGas(b *testing.B, f *Fixture, entryPoint string) {
+	measure, ok := gasMeasurements(f)[entryPoint]
+	if !ok {
+		b.Fatalf("no entry point named %q", entryPoint)
+	}
+	var gas uint64
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		used, err := measureGas(f.ChainA, measure)
+		if err != nil {
+			b.Fatal(err)
+		}
+		gas = used
+	}
+	b.ReportMetric(float64(gas), "gas/op")
+}
+
+// The gas `measure` uses on a cached context of `chain`.
+func measureGas(chain *ibctesting.TestChain, measure func(ctx sdk.Context) error) (uint64, error) {
+	ctx, _ := chain.GetContext().CacheContext()
+	ctx = ctx.WithGasMeter(sdk.NewGasMeter(gasMeasurementLimit))
+	if err := measure(ctx); err != nil {
+		return 0, err
+	}
+	return ctx.GasMeter().GasConsumed(), nil
+}
+
+// Calls of each entry point of the contract on chain A of the
+// fixture, by entry point.
+func gasMeasurements(f *Fixture) map[string]func(ctx sdk.Context) error {
+	chain := f.ChainA
+	k := chain.App.WasmKeeper
+	channel := wasmChannel(f.Path.EndpointA)
+	// a packet sent from B's contract to A's.
+	incoming := wasmvmtypes.IBCPacket{
+		Data:     []byte(`{"increment":{}}`),
+		Src:      channel.CounterpartyEndpoint,
+		Dest:     channel.Endpoint,
+		Sequence: 1,
+		Timeout:  wasmvmtypes.IBCTimeout{Timestamp: uint64(chain.CurrentHeader.Time.Add(DefaultTimeout).UnixNano())},
+	}
+	// a packet sent from A's contract to B's.
+	outgoing := incoming
+	outgoing.Src, outgoing.Dest = incoming.Dest, incoming.Src
+	relayer := chain.SenderAccount.GetAddress().String()
+
+	return map[string]func(ctx sdk.Context) error{
+		GasExecuteIncrement: func(ctx sdk.Context) error {
+			msg, err := json.Marshal(ExecuteMsg{Increment: &Increment{Channel: channel.Endpoint.ChannelID}})
+			if err != nil {
+				return err
+			}
+			_, err = wasmkeeper.NewDefaultPermissionKeeper(k).Execute(ctx, f.ContractA, f.A.Address, msg, nil)
+			return err
+		},
+		GasQueryGetCount: func(ctx sdk.Context) error {
+			msg, err := json.Marshal(QueryMsg{GetCount: &GetCount{Channel: channel.Endpoint.ChannelID}})
+			if err != nil {
+				return err
+			}
+			_, err = k.QuerySmart(ctx, f.ContractA, msg)
+			return err
+		},
+		GasChannelOpen: func(ctx sdk.Context) error {
+			_, err := k.OnOpenChannel(ctx, f.ContractA, wasmvmtypes.IBCChannelOpenMsg{
+				OpenInit: &wasmvmtypes.IBCOpenInit{Channel: channel},
+			})
+			return err
+		},
+		GasChannelConnect: func(ctx sdk.Context) error {
+			return k.OnConnectChannel(ctx, f.ContractA, wasmvmtypes.IBCChannelConnectMsg{
+				OpenAck: &wasmvmtypes.IBCOpenAck{Channel: channel, CounterpartyVersion: Version},
+			})
+		},
+		GasPacketReceive: func(ctx sdk.Context) error {
+			_, err := k.OnRecvPacket(ctx, f.ContractA, wasmvmtypes.IBCPacketReceiveMsg{
+				Packet:  incoming,
+				Relayer: relayer,
+			})
+			return err
+		},
+		GasPacketAck: func(ctx sdk.Context) error {
+			return k.OnAckPacket(ctx, f.ContractA, wasmvmtypes.IBCPacketAckMsg{
+				Acknowledgement: wasmvmtypes.IBCAcknowledgement{Data: []byte(`{"result":"MQ=="}`)},
+				OriginalPacket:  outgoing,
+				Relayer:         relayer,
+			})
+		},
+		GasPacketTimeout: func(ctx sdk.Context) error {
+			return k.OnTimeoutPacket(ctx, f.ContractA, wasmvmtypes.IBCPacketTimeoutMsg{
+				Packet:  outgoing,
+				Relayer: relayer,
+			})
+		},
+	}
+}
+
+// The wasmvm view of an endpoint's channel, as the contract sees it.
+func wasmChannel(endpoint *ibctesting.Endpoint) wasmvmtypes.IBCChannel {
+	return wasmvmtypes.IBCChannel{
+		Endpoint: wasmvmtypes.IBCEndpoint{
+			PortID:    endpoint.ChannelConfig.PortID,
+			ChannelID: endpoint.ChannelID,
+		},
+		CounterpartyEndpoint: wasmvmtypes.IBCEndpoint{
+			PortID:    endpoint.Counterparty.ChannelConfig.PortID,
+			ChannelID: endpoint.Counterparty.ChannelID,
+		},
+		Order:        wasmvmtypes.Unordered,
+		Version:      endpoint.ChannelConfig.Version,
+		ConnectionID: endpoint.ConnectionID,
+	}
+}
@@ -0,0 +1,69 @@
+package simtests
+
+import (
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const gasBudgetsFile = "testdata/gas_budgets.json"
+
+// Fails if any entry point's gas usage grows by more than the
+// tolerance in `testdata/gas_budgets.json`. Relayers pay for our
+// receive path, so gas growth costs real money. After an intended
+// change, rewrite the budgets with `go test -run TestGasBudgets -update`.
+func TestGasBudgets(t *testing.T) {
+	f := SetupFixture(t)
+	measured := MeasureGas(t, f)
+
+	names := make([]string, 0, len(measured))
+	for name := range measured {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t.Logf("%-20s %d", name, measured[name])
+	}
+
+	if *update {
+		budgets := GasBudgets{Tolerance: 0.05}
+		if _, err := os.Stat(gasBudgetsFile); err == nil {
+			budgets = ReadGasBudgets(t, gasBudgetsFile)
+		}
+		budgets.Budgets = measured
+		WriteGasBudgets(t, gasBudgetsFile, budgets)
+		return
+	}
+	ReadGasBudgets(t, gasBudgetsFile).Check(t, measured)
+}
+
+func benchmarkGas(b *testing.B, entryPoint string) {
+	BenchmarkGas(b, SetupFixture(b), entryPoint)
+}
+
+func BenchmarkExecuteIncrement(b *testing.B) { benchmarkGas(b, GasExecuteIncrement) }
+func BenchmarkQueryGetCount(b *testing.B)    { benchmarkGas(b, GasQueryGetCount) }
+func BenchmarkChannelOpen(b *testing.B)      { benchmarkGas(b, GasChannelOpen) }
+func BenchmarkChannelConnect(b *testing.B)   { benchmarkGas(b, GasChannelConnect) }
+func BenchmarkPacketReceive(b *testing.B)    { benchmarkGas(b, GasPacketReceive) }
+func BenchmarkPacketAck(b *testing.B)        { benchmarkGas(b, GasPacketAck) }
+func BenchmarkPacketTimeout(b *testing.B)    { benchmarkGas(b, GasPacketTimeout) }
+
+func TestGasBudgetViolations(t *testing.T) {
+	budgets := GasBudgets{
+		Tolerance: 0.1,
+		Budgets:   map[string]uint64{GasPacketReceive: 1000},
+	}
+
+	require.Empty(t, budgets.Violations(map[string]uint64{GasPacketReceive: 1100}))
+
+	problems := budgets.Violations(map[string]uint64{GasPacketReceive: 1101})
+	require.Len(t, problems, 1)
+	require.Contains(t, problems[0], "over its budget of 1000")
+
+	problems = budgets.Violations(map[string]uint64{GasPacketAck: 1})
+	require.Len(t, problems, 1)
+	require.Contains(t, problems[0], "no gas budget")
+}
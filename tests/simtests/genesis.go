@@ -0,0 +1,116 @@
+package simtests
+
+import (
+	"testing"
+
+	"github.com/CosmWasm/wasmd/app"
+	"github.com/CosmWasm/wasmd/x/wasm"
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	servertypes "github.com/cosmos/cosmos-sdk/server/types"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/log"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	dbm "github.com/tendermint/tm-db"
+)
+
+// Exports `chain`'s state as genesis and replaces its app with a new
+// one booted from that genesis, as happens when a real chain is
+// restarted from an export. The chain keeps its ID, validators and
+// height, so the counterparty's client of it keeps working, and
+// accounts, contracts, clients, channels and packet commitments all
+// come from the export. `opts` should be the options the chain was
+// created with.
+//
+// Importing genesis renumbers accounts, so the chain's sender accounts
+// are reloaded and any `Account`s on it need `Account.Reload`ing
+// (`Fixture.RestartFromGenesis` does this for the fixture's). The
+// pending block is dropped, so call this between transactions.
+// Returns the exported genesis.
+func RestartFromGenesis(t *testing.T, chain *ibctesting.TestChain, opts ...wasm.Option) servertypes.ExportedApp {
+	var exported servertypes.ExportedApp
+	recordUnreplayable(chain, "RestartFromGenesis", func() {
+		exported = restartFromGenesis(t, chain, opts...)
+	})
+	return exported
+}
+
+func restartFromGenesis(t *testing.T, chain *ibctesting.TestChain, opts ...wasm.Option) servertypes.ExportedApp {
+	exported, err := chain.App.ExportAppStateAndValidators(false, nil)
+	require.NoError(t, err)
+	history := chain.App.StakingKeeper.GetAllHistoricalInfo(chain.GetContext())
+
+	// wasmvm locks its cache directory, so the new app can't share
+	// the old one's home.
+	restarted := app.NewWasmApp(log.NewNopLogger(), dbm.NewMemDB(), nil, true, map[int64]bool{}, t.TempDir(), 5, app.MakeEncodingConfig(), wasm.EnableAllProposals, app.EmptyBaseAppOptions{}, opts)
+	restarted.InitChain(abci.RequestInitChain{
+		ChainId:         chain.ChainID,
+		InitialHeight:   exported.Height,
+		ConsensusParams: exported.ConsensusParams,
+		AppStateBytes:   exported.AppState,
+	})
+	// Relayers look up the validators that signed old headers in
+	// Tendermint, which keeps them over a restart. Here they come
+	// from x/staking's historical info, which isn't exported, so
+	// carry it over.
+	ctx := restarted.NewUncachedContext(false, tmproto.Header{})
+	for i := range history {
+		restarted.StakingKeeper.SetHistoricalInfo(ctx, history[i].Header.Height, &history[i])
+	}
+	// The genesis state is committed as the pending block so that
+	// the chain's heights carry on from where they were.
+	restarted.Commit()
+
+	chain.App = restarted
+	chain.QueryServer = restarted.IBCKeeper
+	chain.Codec = restarted.AppCodec()
+	// the module's port and channels come from the export, but the
+	// new app's router doesn't know it.
+	if module := moduleOn(chain); module != nil {
+		routeModule(restarted, module)
+	}
+	for i := range chain.SenderAccounts {
+		sender := &chain.SenderAccounts[i]
+		sender.SenderAccount = restarted.AccountKeeper.GetAccount(ctx, sender.SenderAccount.GetAddress())
+		if sender.SenderPrivKey.Equals(chain.SenderPrivKey) {
+			chain.SenderAccount = sender.SenderAccount
+		}
+	}
+	recorders.Lock()
+	if r := recorders.m[chain.Coordinator]; r != nil {
+		restarted.SetStreamingService(&blockListener{chain: chain.ChainID, recorder: r})
+	}
+	recorders.Unlock()
+
+	// Like `TestChain.NextBlock`, minus ending and committing the
+	// block, which `InitChain` and `Commit` did above.
+	chain.LastHeader = chain.CurrentTMClientHeader()
+	chain.CurrentHeader = tmproto.Header{
+		ChainID:            chain.ChainID,
+		Height:             restarted.LastBlockHeight() + 1,
+		AppHash:            restarted.LastCommitID().Hash,
+		Time:               chain.CurrentHeader.Time,
+		ValidatorsHash:     chain.Vals.Hash(),
+		NextValidatorsHash: chain.NextVals.Hash(),
+	}
+	restarted.BeginBlock(abci.RequestBeginBlock{Header: chain.CurrentHeader})
+
+	// Proofs are made against the state before the latest block,
+	// and the new app has no state before genesis, so commit one
+	// more block before anything asks for a proof.
+	chain.NextBlock()
+	return exported
+}
+
+// Restarts one of the fixture's chains with `RestartFromGenesis`,
+// using the wasm options it was created with, and reloads the
+// fixture's account on it.
+func (f *Fixture) RestartFromGenesis(t *testing.T, chain *ibctesting.TestChain) servertypes.ExportedApp {
+	exported := RestartFromGenesis(t, chain, f.wasmOptions(chain)...)
+	for _, account := range []*Account{&f.A, &f.B} {
+		if account.Chain == chain {
+			account.Reload()
+		}
+	}
+	return exported
+}
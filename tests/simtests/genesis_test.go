@@ -0,0 +1,80 @@
+package simtests
+
+import (
+	"encoding/json"
+	"testing"
+
+	ibctypes "github.com/cosmos/ibc-go/v4/modules/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestartFromGenesis(t *testing.T) {
+	f := SetupFixture(t)
+	channelA, channelB := f.Path.EndpointA.ChannelID, f.Path.EndpointB.ChannelID
+
+	for i := 0; i < 2; i++ {
+		_, err := f.A.ExecuteIncrement(t, &f.ContractA, channelA)
+		require.NoError(t, err)
+	}
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+	// leave a packet in flight from A so its commitment has to
+	// survive the restart.
+	_, err := f.A.ExecuteIncrement(t, &f.ContractA, channelA)
+	require.NoError(t, err)
+	before := ReadContractState(t, f.ChainA, f.ContractA)
+	height := f.ChainA.CurrentHeader.Height
+
+	exported := f.RestartFromGenesis(t, f.ChainA)
+	require.Equal(t, height, exported.Height)
+	require.Greater(t, f.ChainA.CurrentHeader.Height, height)
+
+	var appState map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(exported.AppState, &appState))
+	var ibcGenesis ibctypes.GenesisState
+	require.NoError(t, f.ChainA.App.AppCodec().UnmarshalJSON(appState["ibc"], &ibcGenesis))
+	require.Len(t, ibcGenesis.ChannelGenesis.Commitments, 1)
+
+	port := f.ChainA.ContractInfo(f.ContractA).IBCPortID
+	require.True(t, f.ChainA.App.IBCKeeper.PortKeeper.IsBound(f.ChainA.GetContext(), port), "%s isn't bound after the restart", port)
+	require.Equal(t, before, ReadContractState(t, f.ChainA, f.ContractA))
+	f.RequireChannelOpen(t, f.Path.EndpointA)
+
+	// B has counted the packets relayed before, and still has after
+	// its own restart.
+	f.RequireCount(t, f.CounterB(), channelB, 2)
+	beforeB := ReadContractState(t, f.ChainB, f.ContractB)
+	f.RestartFromGenesis(t, f.ChainB)
+	f.RequireCount(t, f.CounterB(), channelB, 2)
+	require.Equal(t, beforeB, ReadContractState(t, f.ChainB, f.ContractB))
+
+	// the packet sent before the restart is delivered and
+	// acknowledged by the restarted chain, and new packets flow both
+	// ways.
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+	f.RequireCount(t, f.CounterB(), channelB, 3)
+	_, err = f.A.ExecuteIncrement(t, &f.ContractA, channelA)
+	require.NoError(t, err)
+	_, err = f.B.ExecuteIncrement(t, &f.ContractB, channelB)
+	require.NoError(t, err)
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+	f.RequireCount(t, f.CounterA(), channelA, 1)
+	f.RequireCount(t, f.CounterB(), channelB, 4)
+	f.RequireConverged(t)
+}
+
+func TestRestartBothChainsFromGenesis(t *testing.T) {
+	f := SetupFixture(t)
+	channelA, channelB := f.Path.EndpointA.ChannelID, f.Path.EndpointB.ChannelID
+
+	_, err := f.B.ExecuteIncrement(t, &f.ContractB, channelB)
+	require.NoError(t, err)
+	f.RestartFromGenesis(t, f.ChainA)
+	f.RestartFromGenesis(t, f.ChainB)
+
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+	f.RequireCount(t, f.CounterA(), channelA, 1)
+	// clients are updated with headers from the restarted chains.
+	require.NoError(t, f.Path.EndpointA.UpdateClient())
+	require.NoError(t, f.Path.EndpointB.UpdateClient())
+	f.RequireConverged(t)
+}
@@ -0,0 +1,35 @@
+package simtests
+
+import (
+	"net"
+	"testing"
+
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	"github.com/cosmos/cosmos-sdk/server/grpc/gogoreflection"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// Serves a chain's gRPC query services (wasm, ibc, bank, and the
+// rest of the app's modules) on a localhost port and returns its
+// address, so that tools outside of the test, like `grpcurl` or the
+// query clients generated for each module, can query the simulated
+// chain the way they would a validator.
+//
+// Queries see the last committed block, or the height in the
+// `x-cosmos-block-height` header. The server is stopped when the test
+// finishes.
+func ServeGRPC(t *testing.T, chain *ibctesting.TestChain) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	chain.App.RegisterGRPCServer(srv)
+	// lets clients list the services, as `grpcurl` does.
+	gogoreflection.Register(srv)
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+	t.Cleanup(srv.Stop)
+	return listener.Addr().String()
+}
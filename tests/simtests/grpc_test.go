@@ -0,0 +1,79 @@
+package simtests
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	wasmtypes "github.com/CosmWasm/wasmd/x/wasm/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	clienttypes "github.com/cosmos/ibc-go/v4/modules/core/02-client/types"
+	connectiontypes "github.com/cosmos/ibc-go/v4/modules/core/03-connection/types"
+	channeltypes "github.com/cosmos/ibc-go/v4/modules/core/04-channel/types"
+	"github.com/cosmos/ibc-go/v4/modules/core/exported"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/local"
+)
+
+func TestServeGRPC(t *testing.T) {
+	f := SetupFixture(t)
+	_, err := f.A.ExecuteIncrement(t, &f.ContractA, f.Path.EndpointA.ChannelID)
+	require.NoError(t, err)
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+
+	ctx := context.Background()
+	conn, err := grpc.Dial(ServeGRPC(t, f.ChainB), grpc.WithTransportCredentials(local.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	wasm := wasmtypes.NewQueryClient(conn)
+	query, err := json.Marshal(QueryMsg{GetCount: &GetCount{Channel: f.Path.EndpointB.ChannelID}})
+	require.NoError(t, err)
+	smart, err := wasm.SmartContractState(ctx, &wasmtypes.QuerySmartContractStateRequest{
+		Address:   f.ContractB.String(),
+		QueryData: query,
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"count":1}`, string(smart.Data))
+
+	all, err := wasm.AllContractState(ctx, &wasmtypes.QueryAllContractStateRequest{Address: f.ContractB.String()})
+	require.NoError(t, err)
+	require.NotEmpty(t, all.Models)
+
+	status, err := clienttypes.NewQueryClient(conn).ClientStatus(ctx, &clienttypes.QueryClientStatusRequest{
+		ClientId: f.Path.EndpointB.ClientID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, exported.Active.String(), status.Status)
+
+	connection, err := connectiontypes.NewQueryClient(conn).Connection(ctx, &connectiontypes.QueryConnectionRequest{
+		ConnectionId: f.Path.EndpointB.ConnectionID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, connectiontypes.OPEN, connection.Connection.State)
+
+	channel, err := channeltypes.NewQueryClient(conn).Channel(ctx, &channeltypes.QueryChannelRequest{
+		PortId:    f.Path.EndpointB.ChannelConfig.PortID,
+		ChannelId: f.Path.EndpointB.ChannelID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, channeltypes.OPEN, channel.Channel.State)
+
+	balances, err := banktypes.NewQueryClient(conn).AllBalances(ctx, &banktypes.QueryAllBalancesRequest{
+		Address: f.B.Address.String(),
+	})
+	require.NoError(t, err)
+	require.False(t, balances.Balances.IsZero())
+
+	// queries see blocks committed after the server started.
+	_, err = f.A.ExecuteIncrement(t, &f.ContractA, f.Path.EndpointA.ChannelID)
+	require.NoError(t, err)
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+	smart, err = wasm.SmartContractState(ctx, &wasmtypes.QuerySmartContractStateRequest{
+		Address:   f.ContractB.String(),
+		QueryData: query,
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"count":2}`, string(smart.Data))
+}
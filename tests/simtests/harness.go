@@ -0,0 +1,166 @@
+package simtests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	sdkibctesting "github.com/cosmos/ibc-go/v4/testing"
+	"withoutdoing.com/harness"
+)
+
+// Runs the scenarios in `withoutdoing.com/harness` on simulated
+// chains. The fixture is filled in as the scenario deploys contracts
+// and opens a channel, `SetupFixture` does all of that in one go.
+type Harness struct {
+	t       testing.TB
+	opts    []FixtureOption
+	Fixture *Fixture
+}
+
+var _ harness.Harness = (*Harness)(nil)
+
+// Creates two simulated chains with nothing deployed on them. What
+// the test does to them is recorded, and written to
+// `<name of test>.session.json` next to its timeline when the test
+// finishes (see `Recording`).
+func NewHarness(t testing.TB, opts ...FixtureOption) *Harness {
+	o := newFixtureOptions(opts)
+	seed := Seed(t)
+	if o.seed != nil {
+		seed = *o.seed
+	}
+	c := newSeededCoordinator(t, seed, o.wasm[harness.A], o.wasm[harness.B])
+	f := &Fixture{
+		Coordinator: c,
+		ChainA:      c.GetChain(sdkibctesting.GetChainID(0)),
+		ChainB:      c.GetChain(sdkibctesting.GetChainID(1)),
+		Recorder:    NewRecorder(t, c),
+		Seed:        seed,
+		opts:        o,
+	}
+	f.startRecording(t)
+	return &Harness{t: t, opts: opts, Fixture: f}
+}
+
+// Recorded as a `deploy: [a, b]` step.
+func (h *Harness) Deploy(context.Context) error {
+	f := h.Fixture
+	return f.record(func(*Fixture) RecordedStep {
+		return RecordedStep{Step: &Step{Deploy: []string{"a", "b"}}}
+	}, func() error {
+		for _, side := range []harness.Side{harness.A, harness.B} {
+			if err := f.deploy(h.t, side); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Deploys what the fixture's options put on one side, a module,
+// another wasm file or `WasmFile`, and makes the side's account.
+func (f *Fixture) deploy(t testing.TB, side harness.Side) error {
+	chain, contract, account := f.ChainA, &f.ContractA, &f.A
+	if side == harness.B {
+		chain, contract, account = f.ChainB, &f.ContractB, &f.B
+	}
+	switch {
+	case f.opts.modules[side] != nil:
+		if err := deployModule(t, chain, f.opts.modules[side]); err != nil {
+			return err
+		}
+	case f.opts.wasmFiles[side] != "":
+		chain.StoreCodeFile(f.opts.wasmFiles[side])
+		*contract = Instantiate(t, chain, 1)
+	default:
+		chain.StoreCodeFile(WasmFile)
+		*contract = Instantiate(t, chain, 1)
+	}
+	*account = GenAccount(t, chain)
+	return nil
+}
+
+// Whether one side has been deployed to.
+func (f *Fixture) deployed(side harness.Side) bool {
+	if side == harness.A {
+		return f.A.Chain != nil
+	}
+	return f.B.Chain != nil
+}
+
+func (h *Harness) OpenChannel(context.Context) error {
+	return h.Fixture.record(func(*Fixture) RecordedStep {
+		return RecordedStep{HarnessOpenChannel: true}
+	}, h.openChannel)
+}
+
+func (h *Harness) openChannel() error {
+	f := h.Fixture
+	if !f.deployed(harness.A) || !f.deployed(harness.B) {
+		return errors.New("contracts have not been deployed")
+	}
+	path := ibctesting.NewPath(f.ChainA, f.ChainB)
+	path.EndpointA.ChannelConfig = ChannelConfig(f.CounterA().Port(), h.opts...)
+	path.EndpointB.ChannelConfig = ChannelConfig(f.CounterB().Port(), h.opts...)
+	f.Coordinator.SetupConnections(path)
+	if err := OpenChannel(path); err != nil {
+		return err
+	}
+	f.Path = path
+	if newFixtureOptions(h.opts).transfer {
+		transfer, err := openChannelOn(path, TransferChannelConfig(h.opts...), TransferChannelConfig(h.opts...))
+		if err != nil {
+			return fmt.Errorf("opening transfer channel: %w", err)
+		}
+		f.TransferPath = transfer
+	}
+	return nil
+}
+
+func (h *Harness) Increment(_ context.Context, side harness.Side) error {
+	account, contract, endpoint, err := h.side(side)
+	if err != nil {
+		return err
+	}
+	return contract.increment(chainT(h.t), account, endpoint.ChannelID)
+}
+
+func (h *Harness) Relay(context.Context) error {
+	if h.Fixture.Path == nil {
+		return errors.New("no channel has been opened")
+	}
+	return RelayAndAckPendingPackets(h.Fixture.Path)
+}
+
+func (h *Harness) Count(_ context.Context, side harness.Side) (uint32, error) {
+	_, contract, endpoint, err := h.side(side)
+	if err != nil {
+		return 0, err
+	}
+	count, _, err := contract.Counts(endpoint.ChannelID)
+	return count, err
+}
+
+func (h *Harness) TimeoutCount(_ context.Context, side harness.Side) (uint32, error) {
+	_, contract, endpoint, err := h.side(side)
+	if err != nil {
+		return 0, err
+	}
+	_, timeouts, err := contract.Counts(endpoint.ChannelID)
+	return timeouts, err
+}
+
+// The account, contract and channel endpoint on one side.
+func (h *Harness) side(side harness.Side) (*Account, Contract, *ibctesting.Endpoint, error) {
+	f := h.Fixture
+	if f.Path == nil {
+		return nil, Contract{}, nil, errors.New("no channel has been opened")
+	}
+	if side == harness.A {
+		return &f.A, f.CounterA(), f.Path.EndpointA, nil
+	}
+	return &f.B, f.CounterB(), f.Path.EndpointB, nil
+}
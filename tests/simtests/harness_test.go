@@ -0,0 +1,54 @@
+package simtests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"withoutdoing.com/harness"
+)
+
+func TestHarnessScenarios(t *testing.T) {
+	for _, s := range harness.Scenarios {
+		s := s
+		t.Run(s.Name, func(t *testing.T) {
+			s.Run(context.Background(), t, NewHarness(t))
+		})
+	}
+}
+
+func TestHarnessOrdering(t *testing.T) {
+	ctx := context.Background()
+	h := NewHarness(t)
+
+	require.EqualError(t, h.OpenChannel(ctx), "contracts have not been deployed")
+	require.NoError(t, h.Deploy(ctx))
+	require.EqualError(t, h.Increment(ctx, harness.A), "no channel has been opened")
+	require.EqualError(t, h.Relay(ctx), "no channel has been opened")
+	_, err := h.Count(ctx, harness.B)
+	require.EqualError(t, err, "no channel has been opened")
+	require.NoError(t, h.OpenChannel(ctx))
+}
+
+func TestHarnessTimeoutCount(t *testing.T) {
+	ctx := context.Background()
+	h := NewHarness(t)
+	require.NoError(t, h.Deploy(ctx))
+	require.NoError(t, h.OpenChannel(ctx))
+
+	require.NoError(t, h.Increment(ctx, harness.B))
+	f := h.Fixture
+	f.Coordinator.IncrementTimeBy(DefaultTimeout + time.Minute)
+	require.NoError(t, TimeoutPendingPackets(f.Path))
+
+	timeouts, err := h.TimeoutCount(ctx, harness.B)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), timeouts)
+	timeouts, err = h.TimeoutCount(ctx, harness.A)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), timeouts)
+	count, err := h.Count(ctx, harness.A)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), count)
+}
@@ -0,0 +1,255 @@
+package simtests
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CosmWasm/wasmd/app"
+	"github.com/CosmWasm/wasmd/x/wasm"
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	capabilitykeeper "github.com/cosmos/cosmos-sdk/x/capability/keeper"
+	capabilitytypes "github.com/cosmos/cosmos-sdk/x/capability/types"
+	icacontrollertypes "github.com/cosmos/ibc-go/v4/modules/apps/27-interchain-accounts/controller/types"
+	icahosttypes "github.com/cosmos/ibc-go/v4/modules/apps/27-interchain-accounts/host/types"
+	ibcfee "github.com/cosmos/ibc-go/v4/modules/apps/29-fee"
+	ibctransfertypes "github.com/cosmos/ibc-go/v4/modules/apps/transfer/types"
+	clienttypes "github.com/cosmos/ibc-go/v4/modules/core/02-client/types"
+	channeltypes "github.com/cosmos/ibc-go/v4/modules/core/04-channel/types"
+	porttypes "github.com/cosmos/ibc-go/v4/modules/core/05-port/types"
+	host "github.com/cosmos/ibc-go/v4/modules/core/24-host"
+	"github.com/cosmos/ibc-go/v4/modules/core/exported"
+	intertxtypes "github.com/cosmos/interchain-accounts/x/inter-tx/types"
+)
+
+// A counter-1 implementation written in Go as an IBC module, which
+// a fixture runs in place of the contract on one of its chains (see
+// `WithModule`). `Peer` is one.
+//
+// The module binds its port when it's deployed, and the chain's IBC
+// router sends that port to it, behind the ICS-29 fee middleware like
+// the chain's other modules. Its callbacks get the chain's context,
+// so state it keeps in the chain's store is committed and reverted
+// with the chain's.
+type Module interface {
+	porttypes.IBCModule
+	// The port the module binds.
+	PortID() string
+	// Called when the module is deployed, before its port is bound.
+	// `env` is how the module claims channels and sends packets.
+	Deploy(env ModuleEnv)
+	// Sends an increment over `channel`, as the contract does when
+	// executed with `increment`.
+	Increment(channel string) error
+	// The number of increments received over `channel`.
+	Count(channel string) uint32
+	// The number of the module's packets sent over `channel` that
+	// timed out.
+	TimeoutCount(channel string) uint32
+}
+
+// The chain a module is deployed on.
+type ModuleEnv struct {
+	Chain *ibctesting.TestChain
+}
+
+// The keeper of the module's port and channel capabilities. A module
+// must claim the capability of every channel it opens to send over
+// it.
+//
+// The chains' app seals its capability keeper when it's built, so a
+// module can't have a scoped keeper of its own. It shares the
+// inter-tx module's, which the simulated chains don't otherwise use.
+func (e ModuleEnv) ScopedKeeper() capabilitykeeper.ScopedKeeper {
+	return e.Chain.App.ScopedInterTxKeeper
+}
+
+// Sends packets through the fee middleware, as the chain's other
+// modules do.
+func (e ModuleEnv) ICS4Wrapper() porttypes.ICS4Wrapper {
+	return e.Chain.App.IBCFeeKeeper
+}
+
+// Sends a packet with `data` from `port` over `channel`, timing out
+// `timeout` after the current block time, or `DefaultTimeout` after
+// it if `timeout` is zero. Like a transaction, sending ends the block
+// and moves the clock on, and the packet is left pending on the
+// chain.
+func (e ModuleEnv) SendPacket(port, channel string, data []byte, timeout time.Duration) error {
+	return record(e.Chain, func(f *Fixture) RecordedStep {
+		return RecordedStep{Packet: &RecordedPacket{Chain: f.chainName(e.Chain), Port: port, Channel: channel, Data: data, Timeout: timeout}}
+	}, func() error {
+		return e.sendPacket(port, channel, data, timeout)
+	})
+}
+
+func (e ModuleEnv) sendPacket(port, channel string, data []byte, timeout time.Duration) error {
+	chain := e.Chain
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	chain.Coordinator.UpdateTimeForChain(chain)
+	height, at := chain.CurrentHeader.Height, chain.Coordinator.CurrentTime
+
+	ctx, write := chain.GetContext().CacheContext()
+	end, ok := chain.App.IBCKeeper.ChannelKeeper.GetChannel(ctx, port, channel)
+	if !ok {
+		return fmt.Errorf("no channel %s on port %s", channel, port)
+	}
+	sequence, ok := chain.App.IBCKeeper.ChannelKeeper.GetNextSequenceSend(ctx, port, channel)
+	if !ok {
+		return fmt.Errorf("no next sequence for %s on port %s", channel, port)
+	}
+	capability, ok := e.ScopedKeeper().GetCapability(ctx, host.ChannelCapabilityPath(port, channel))
+	if !ok {
+		return fmt.Errorf("the module doesn't own %s on port %s", channel, port)
+	}
+	packet := channeltypes.NewPacket(
+		data, sequence,
+		port, channel,
+		end.Counterparty.PortId, end.Counterparty.ChannelId,
+		clienttypes.ZeroHeight(), uint64(ctx.BlockTime().Add(timeout).UnixNano()),
+	)
+	if err := e.ICS4Wrapper().SendPacket(ctx, capability, packet); err != nil {
+		return err
+	}
+	write()
+	recordEvents(chain, height, at, ctx.EventManager().Events())
+
+	chain.NextBlock()
+	chain.Coordinator.IncrementTime()
+	chain.PendingSendPackets = append(chain.PendingSendPackets, packet)
+	return nil
+}
+
+// The module deployed on each chain.
+var modules = struct {
+	sync.Mutex
+	m map[*ibctesting.TestChain]Module
+}{m: map[*ibctesting.TestChain]Module{}}
+
+// Deploys `module` on `chain`: binds its port and routes it to the
+// module until the test finishes.
+//
+// The chains' app seals its IBC router when it's built, so the
+// module can't be added to it. Its port is bound by the inter-tx
+// module instead (see `ModuleEnv.ScopedKeeper`), and the app's router
+// is replaced by one whose inter-tx route sends the module's port to
+// the module and everything else where it went before.
+func deployModule(t testing.TB, chain *ibctesting.TestChain, module Module) error {
+	env := ModuleEnv{Chain: chain}
+	module.Deploy(env)
+	ctx := chain.GetContext()
+	port := module.PortID()
+	if chain.App.IBCKeeper.PortKeeper.IsBound(ctx, port) {
+		return fmt.Errorf("port %s is already bound on %s", port, chain.ChainID)
+	}
+	capability := chain.App.IBCKeeper.PortKeeper.BindPort(ctx, port)
+	if err := env.ScopedKeeper().ClaimCapability(ctx, capability, host.PortPath(port)); err != nil {
+		return err
+	}
+
+	modules.Lock()
+	modules.m[chain] = module
+	modules.Unlock()
+	t.Cleanup(func() {
+		modules.Lock()
+		delete(modules.m, chain)
+		modules.Unlock()
+	})
+	routeModule(chain.App, module)
+	return nil
+}
+
+// The module deployed on `chain`, if there is one.
+func moduleOn(chain *ibctesting.TestChain) Module {
+	modules.Lock()
+	defer modules.Unlock()
+	return modules.m[chain]
+}
+
+// Replaces `wasmApp`'s IBC router with one that routes the module's
+// port to it. The routes are the ones `app.NewWasmApp` adds.
+func routeModule(wasmApp *app.WasmApp, module Module) {
+	old := wasmApp.IBCKeeper.Router
+	router := porttypes.NewRouter()
+	for _, name := range []string{
+		ibctransfertypes.ModuleName,
+		wasm.ModuleName,
+		intertxtypes.ModuleName,
+		icacontrollertypes.SubModuleName,
+		icahosttypes.SubModuleName,
+	} {
+		route, ok := old.GetRoute(name)
+		if !ok {
+			panic(fmt.Sprintf("the app has no %s route", name))
+		}
+		if name == intertxtypes.ModuleName {
+			if r, ok := route.(moduleRouter); ok {
+				route = r.next
+			}
+			route = moduleRouter{
+				next:   route,
+				port:   module.PortID(),
+				module: ibcfee.NewIBCMiddleware(module, wasmApp.IBCFeeKeeper),
+			}
+		}
+		router.AddRoute(name, route)
+	}
+	router.Seal()
+	wasmApp.IBCKeeper.Router = router
+	wasmApp.IBCKeeper.PortKeeper.Router = router
+}
+
+// Sends the callbacks for `port` to `module` and all others to
+// `next`.
+type moduleRouter struct {
+	next   porttypes.IBCModule
+	port   string
+	module porttypes.IBCModule
+}
+
+func (r moduleRouter) route(port string) porttypes.IBCModule {
+	if port == r.port {
+		return r.module
+	}
+	return r.next
+}
+
+func (r moduleRouter) OnChanOpenInit(ctx sdk.Context, order channeltypes.Order, connectionHops []string, portID, channelID string, channelCap *capabilitytypes.Capability, counterparty channeltypes.Counterparty, version string) (string, error) {
+	return r.route(portID).OnChanOpenInit(ctx, order, connectionHops, portID, channelID, channelCap, counterparty, version)
+}
+
+func (r moduleRouter) OnChanOpenTry(ctx sdk.Context, order channeltypes.Order, connectionHops []string, portID, channelID string, channelCap *capabilitytypes.Capability, counterparty channeltypes.Counterparty, counterpartyVersion string) (string, error) {
+	return r.route(portID).OnChanOpenTry(ctx, order, connectionHops, portID, channelID, channelCap, counterparty, counterpartyVersion)
+}
+
+func (r moduleRouter) OnChanOpenAck(ctx sdk.Context, portID, channelID, counterpartyChannelID, counterpartyVersion string) error {
+	return r.route(portID).OnChanOpenAck(ctx, portID, channelID, counterpartyChannelID, counterpartyVersion)
+}
+
+func (r moduleRouter) OnChanOpenConfirm(ctx sdk.Context, portID, channelID string) error {
+	return r.route(portID).OnChanOpenConfirm(ctx, portID, channelID)
+}
+
+func (r moduleRouter) OnChanCloseInit(ctx sdk.Context, portID, channelID string) error {
+	return r.route(portID).OnChanCloseInit(ctx, portID, channelID)
+}
+
+func (r moduleRouter) OnChanCloseConfirm(ctx sdk.Context, portID, channelID string) error {
+	return r.route(portID).OnChanCloseConfirm(ctx, portID, channelID)
+}
+
+func (r moduleRouter) OnRecvPacket(ctx sdk.Context, packet channeltypes.Packet, relayer sdk.AccAddress) exported.Acknowledgement {
+	return r.route(packet.DestinationPort).OnRecvPacket(ctx, packet, relayer)
+}
+
+func (r moduleRouter) OnAcknowledgementPacket(ctx sdk.Context, packet channeltypes.Packet, acknowledgement []byte, relayer sdk.AccAddress) error {
+	return r.route(packet.SourcePort).OnAcknowledgementPacket(ctx, packet, acknowledgement, relayer)
+}
+
+func (r moduleRouter) OnTimeoutPacket(ctx sdk.Context, packet channeltypes.Packet, relayer sdk.AccAddress) error {
+	return r.route(packet.SourcePort).OnTimeoutPacket(ctx, packet, relayer)
+}
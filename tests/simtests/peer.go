@@ -0,0 +1,290 @@
+package simtests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	capabilitytypes "github.com/cosmos/cosmos-sdk/x/capability/types"
+	channeltypes "github.com/cosmos/ibc-go/v4/modules/core/04-channel/types"
+	host "github.com/cosmos/ibc-go/v4/modules/core/24-host"
+	"github.com/cosmos/ibc-go/v4/modules/core/exported"
+)
+
+// A counter-1 implementation written in Go, for testing the contract
+// against a counterparty that does exactly what the test tells it
+// to.
+//
+// The peer is a `Module`, so it takes part in handshakes and in
+// relaying through core IBC, with no wasm involved. It counts
+// increments it receives and its packets that time out like the
+// contract does, records every packet it receives, every
+// acknowledgement of its own packets and every timeout, and sends
+// packets with `SendPacket` and `Increment`.
+//
+// Counts and records are kept in memory, so they survive
+// `RestartFromGenesis`, and aren't rolled back by failed
+// transactions.
+type Peer struct {
+	// The port the peer binds. "" means `PeerPort`.
+	Port string
+	// The version the peer proposes and accepts in handshakes. ""
+	// means `Version`.
+	Version string
+	// Accept channels with any order and version, to see how the
+	// other side copes. Handshakes are answered with `Version` if it's
+	// set, and with whatever was proposed if not.
+	Permissive bool
+	// How the peer acknowledges packets. Nil acknowledges increments
+	// with success and anything else with an error, as the contract
+	// does.
+	Ack PeerAck
+
+	// Packets the peer has received, in order.
+	Received []channeltypes.Packet
+	// Acknowledgements of the peer's packets, in order.
+	Acks []PeerAcknowledgement
+	// The peer's packets that timed out, in order.
+	TimedOut []channeltypes.Packet
+
+	env           ModuleEnv
+	counts        map[string]uint32
+	timeoutCounts map[string]uint32
+}
+
+var _ Module = (*Peer)(nil)
+
+// The port a peer binds if it isn't given one.
+const PeerPort = "counter"
+
+// An acknowledgement of one of a peer's packets.
+type PeerAcknowledgement struct {
+	Packet          channeltypes.Packet
+	Acknowledgement []byte
+}
+
+// What a peer acknowledges a received packet with. Returning nil
+// doesn't acknowledge the packet at all, which core IBC treats as an
+// asynchronous acknowledgement that never comes.
+type PeerAck func(packet channeltypes.Packet) []byte
+
+// Acknowledges packets with the contract's success ack.
+func AckSuccess(channeltypes.Packet) []byte {
+	return successAck
+}
+
+// Acknowledges packets with an error ack holding `msg`.
+func AckError(msg string) PeerAck {
+	return func(channeltypes.Packet) []byte {
+		return errorAck(msg)
+	}
+}
+
+// Acknowledges packets with `ack` as is, for example with bytes that
+// aren't an ack at all.
+func AckBytes(ack []byte) PeerAck {
+	return func(channeltypes.Packet) []byte {
+		return ack
+	}
+}
+
+// What the contract acknowledges packets with. See `../../src/ack.rs`.
+type Ack struct {
+	Result []byte `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+var successAck = mustMarshal(Ack{Result: []byte("1")})
+
+func errorAck(msg string) []byte {
+	return mustMarshal(Ack{Error: msg})
+}
+
+func mustMarshal(v any) []byte {
+	bz, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+// A peer's acknowledgement, which core IBC writes as is. It's always
+// a success as far as core IBC is concerned, as the peer's state
+// isn't in the chain's store for it to revert.
+type peerAcknowledgement []byte
+
+func (a peerAcknowledgement) Success() bool           { return true }
+func (a peerAcknowledgement) Acknowledgement() []byte { return a }
+
+// The port the peer binds.
+func (p *Peer) PortID() string {
+	if p.Port == "" {
+		return PeerPort
+	}
+	return p.Port
+}
+
+// The number of increments the peer has received over `channel`.
+func (p *Peer) Count(channel string) uint32 {
+	return p.counts[channel]
+}
+
+// The number of the peer's packets sent over `channel` that timed
+// out.
+func (p *Peer) TimeoutCount(channel string) uint32 {
+	return p.timeoutCounts[channel]
+}
+
+// Sends an increment over `channel`, as the contract does when
+// executed with `increment`.
+func (p *Peer) Increment(channel string) error {
+	return p.SendPacket(channel, mustMarshal(IbcExecuteMsg{Increment: &IbcIncrement{}}), 0)
+}
+
+// Sends a packet with any data over `channel`. See
+// `ModuleEnv.SendPacket`.
+func (p *Peer) SendPacket(channel string, data []byte, timeout time.Duration) error {
+	if p.env.Chain == nil {
+		return errors.New("the peer has not been deployed")
+	}
+	return p.env.SendPacket(p.PortID(), channel, data, timeout)
+}
+
+// Keeps the chain the peer is deployed on. See `Module`.
+func (p *Peer) Deploy(env ModuleEnv) {
+	p.env = env
+}
+
+func (p *Peer) OnChanOpenInit(ctx sdk.Context, order channeltypes.Order, _ []string, portID, channelID string, channelCap *capabilitytypes.Capability, _ channeltypes.Counterparty, version string) (string, error) {
+	if err := p.validateChannel(order, version, ""); err != nil {
+		return "", err
+	}
+	if err := p.claim(ctx, channelCap, portID, channelID); err != nil {
+		return "", err
+	}
+	if p.Permissive && p.Version == "" {
+		return version, nil
+	}
+	return p.version(), nil
+}
+
+func (p *Peer) OnChanOpenTry(ctx sdk.Context, order channeltypes.Order, _ []string, portID, channelID string, channelCap *capabilitytypes.Capability, _ channeltypes.Counterparty, counterpartyVersion string) (string, error) {
+	if err := p.validateChannel(order, counterpartyVersion, counterpartyVersion); err != nil {
+		return "", err
+	}
+	if err := p.claim(ctx, channelCap, portID, channelID); err != nil {
+		return "", err
+	}
+	if p.Permissive && p.Version == "" {
+		return counterpartyVersion, nil
+	}
+	return p.version(), nil
+}
+
+func (p *Peer) OnChanOpenAck(_ sdk.Context, _, channelID, _, counterpartyVersion string) error {
+	if err := p.validateChannel(channeltypes.UNORDERED, p.version(), counterpartyVersion); err != nil {
+		return err
+	}
+	p.connect(channelID)
+	return nil
+}
+
+func (p *Peer) OnChanOpenConfirm(_ sdk.Context, _, channelID string) error {
+	p.connect(channelID)
+	return nil
+}
+
+// Like the contract, only a closed channel's count is removed.
+func (p *Peer) OnChanCloseInit(_ sdk.Context, _, channelID string) error {
+	delete(p.counts, channelID)
+	return nil
+}
+
+func (p *Peer) OnChanCloseConfirm(_ sdk.Context, _, channelID string) error {
+	delete(p.counts, channelID)
+	return nil
+}
+
+func (p *Peer) OnRecvPacket(_ sdk.Context, packet channeltypes.Packet, _ sdk.AccAddress) exported.Acknowledgement {
+	p.Received = append(p.Received, packet)
+
+	var msg IbcExecuteMsg
+	err := json.Unmarshal(packet.GetData(), &msg)
+	if err == nil && msg.Increment == nil {
+		err = errors.New("unknown packet")
+	}
+	if err == nil {
+		if p.counts == nil {
+			p.counts = map[string]uint32{}
+		}
+		p.counts[packet.DestinationChannel]++
+	}
+
+	var ack []byte
+	switch {
+	case p.Ack != nil:
+		ack = p.Ack(packet)
+	case err != nil:
+		ack = errorAck(err.Error())
+	default:
+		ack = successAck
+	}
+	if ack == nil {
+		return nil
+	}
+	return peerAcknowledgement(ack)
+}
+
+func (p *Peer) OnAcknowledgementPacket(_ sdk.Context, packet channeltypes.Packet, acknowledgement []byte, _ sdk.AccAddress) error {
+	p.Acks = append(p.Acks, PeerAcknowledgement{Packet: packet, Acknowledgement: acknowledgement})
+	return nil
+}
+
+func (p *Peer) OnTimeoutPacket(_ sdk.Context, packet channeltypes.Packet, _ sdk.AccAddress) error {
+	p.TimedOut = append(p.TimedOut, packet)
+	if p.timeoutCounts == nil {
+		p.timeoutCounts = map[string]uint32{}
+	}
+	p.timeoutCounts[packet.SourceChannel]++
+	return nil
+}
+
+func (p *Peer) version() string {
+	if p.Version == "" {
+		return Version
+	}
+	return p.Version
+}
+
+// Accepts unordered channels with the peer's version, like the
+// contract's `validate_order_and_version`.
+func (p *Peer) validateChannel(order channeltypes.Order, version, counterpartyVersion string) error {
+	if p.Permissive {
+		return nil
+	}
+	if order != channeltypes.UNORDERED {
+		return errors.New("only unordered channels are supported")
+	}
+	if version != p.version() {
+		return fmt.Errorf("invalid IBC channel version. Got (%s), expected (%s)", version, p.version())
+	}
+	if counterpartyVersion != "" && counterpartyVersion != p.version() {
+		return fmt.Errorf("invalid IBC channel version. Got (%s), expected (%s)", counterpartyVersion, p.version())
+	}
+	return nil
+}
+
+// Takes ownership of a channel being opened, which the peer needs to
+// send over it.
+func (p *Peer) claim(ctx sdk.Context, channelCap *capabilitytypes.Capability, portID, channelID string) error {
+	return p.env.ScopedKeeper().ClaimCapability(ctx, channelCap, host.ChannelCapabilityPath(portID, channelID))
+}
+
+func (p *Peer) connect(channelID string) {
+	if p.counts == nil {
+		p.counts = map[string]uint32{}
+	}
+	p.counts[channelID] = 0
+}
@@ -0,0 +1,145 @@
+package simtests
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	channeltypes "github.com/cosmos/ibc-go/v4/modules/core/04-channel/types"
+	"github.com/stretchr/testify/require"
+	"withoutdoing.com/harness"
+)
+
+// The acks written on `chain`, in order.
+func writtenAcks(f *Fixture, chain *ibctesting.TestChain) []string {
+	var acks []string
+	for _, e := range f.Recorder.Timeline() {
+		if e.Chain == chain.ChainID && e.Type == "write_acknowledgement" {
+			acks = append(acks, e.Attributes["packet_ack"])
+		}
+	}
+	return acks
+}
+
+func TestPeerCounts(t *testing.T) {
+	peer := &Peer{}
+	f := SetupFixture(t, WithPeer(harness.B, peer))
+	channelA, channelB := f.Path.EndpointA.ChannelID, f.Path.EndpointB.ChannelID
+	require.Equal(t, PeerPort, f.Path.EndpointB.ChannelConfig.PortID)
+
+	for i := 0; i < 2; i++ {
+		_, err := f.A.ExecuteIncrement(t, &f.ContractA, channelA)
+		require.NoError(t, err)
+	}
+	require.NoError(t, peer.Increment(channelB))
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+
+	f.RequireCount(t, f.CounterB(), channelB, 2)
+	f.RequireCount(t, f.CounterA(), channelA, 1)
+	require.Len(t, peer.Received, 2)
+	require.Len(t, peer.Acks, 1)
+	require.Equal(t, successAck, peer.Acks[0].Acknowledgement)
+	require.Equal(t, uint32(2), peer.Count(channelB))
+	f.RequireConverged(t)
+}
+
+// The peer isn't wasm, so its chain can run a VM of its own.
+func TestPeerWithVM(t *testing.T) {
+	config := DefaultVMConfig()
+	config.MemoryCacheSize = 10
+	f := SetupFixture(t, WithPeer(harness.B, &Peer{}), WithWasmOptions(harness.B, WithVM(t, config)))
+	_, err := f.A.ExecuteIncrement(t, &f.ContractA, f.Path.EndpointA.ChannelID)
+	require.NoError(t, err)
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+	f.RequireConverged(t)
+}
+
+// The peer's port and channels come back with the rest of the
+// chain's state, and the new app routes them to the peer.
+func TestPeerSurvivesRestart(t *testing.T) {
+	peer := &Peer{}
+	f := SetupFixture(t, WithPeer(harness.B, peer))
+	f.RestartFromGenesis(t, f.ChainB)
+	require.NoError(t, f.Path.EndpointA.UpdateClient())
+
+	_, err := f.A.ExecuteIncrement(t, &f.ContractA, f.Path.EndpointA.ChannelID)
+	require.NoError(t, err)
+	require.NoError(t, peer.Increment(f.Path.EndpointB.ChannelID))
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+	f.RequireCount(t, f.CounterB(), f.Path.EndpointB.ChannelID, 1)
+	f.RequireCount(t, f.CounterA(), f.Path.EndpointA.ChannelID, 1)
+	f.RequireConverged(t)
+}
+
+func TestContractAcceptsAnyAck(t *testing.T) {
+	for name, ack := range map[string]PeerAck{
+		"success":   AckSuccess,
+		"error":     AckError("no thanks"),
+		"malformed": AckBytes([]byte("not an ack")),
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := SetupFixture(t, WithPeer(harness.B, &Peer{Ack: ack}))
+			_, err := f.A.ExecuteIncrement(t, &f.ContractA, f.Path.EndpointA.ChannelID)
+			require.NoError(t, err)
+			require.NoError(t, RelayAndAckPendingPackets(f.Path))
+
+			require.Equal(t, []string{string(ack(channeltypes.Packet{}))}, writtenAcks(f, f.ChainB))
+			f.RequireNoPendingPackets(t)
+			f.RequireTimeoutCount(t, f.CounterA(), f.Path.EndpointA.ChannelID, 0)
+		})
+	}
+}
+
+func TestContractErrorAcksUnknownPackets(t *testing.T) {
+	peer := &Peer{}
+	f := SetupFixture(t, WithPeer(harness.B, peer))
+	channelA, channelB := f.Path.EndpointA.ChannelID, f.Path.EndpointB.ChannelID
+
+	for _, data := range []string{`{"decrement":{}}`, `not json`, `{"increment":{}}`} {
+		require.NoError(t, peer.SendPacket(channelB, []byte(data), 0))
+	}
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+
+	require.Len(t, peer.Acks, 3)
+	for i, ack := range peer.Acks[:2] {
+		var decoded Ack
+		require.NoError(t, json.Unmarshal(ack.Acknowledgement, &decoded), "ack %d", i)
+		require.NotEmpty(t, decoded.Error, "ack %d", i)
+	}
+	require.Equal(t, successAck, peer.Acks[2].Acknowledgement)
+	f.RequireCount(t, f.CounterA(), channelA, 1)
+}
+
+func TestPeerPacketsTimeOut(t *testing.T) {
+	peer := &Peer{}
+	f := SetupFixture(t, WithPeer(harness.B, peer))
+	channelA, channelB := f.Path.EndpointA.ChannelID, f.Path.EndpointB.ChannelID
+
+	require.NoError(t, peer.SendPacket(channelB, []byte(`{"increment":{}}`), 30*time.Second))
+	f.Coordinator.IncrementTimeBy(time.Minute)
+	require.NoError(t, TimeoutPendingPackets(f.Path))
+
+	require.Len(t, peer.TimedOut, 1)
+	require.Empty(t, peer.Acks)
+	f.RequireTimeoutCount(t, f.CounterB(), channelB, 1)
+	f.RequireCount(t, f.CounterA(), channelA, 0)
+}
+
+func TestContractRejectsPeerVersion(t *testing.T) {
+	h := NewHarness(t, WithPeer(harness.B, &Peer{Version: "counter-2"}))
+	require.NoError(t, h.Deploy(context.Background()))
+	f := h.Fixture
+
+	path := ibctesting.NewPath(f.ChainA, f.ChainB)
+	path.EndpointA.ChannelConfig = ChannelConfig(f.CounterA().Port())
+	path.EndpointB.ChannelConfig = ChannelConfig(f.CounterB().Port())
+	path.EndpointB.ChannelConfig.Version = "counter-2"
+	f.Coordinator.SetupConnections(path)
+
+	// the peer proposes its version, which the contract refuses.
+	require.NoError(t, path.EndpointB.ChanOpenInit())
+	err := path.EndpointA.ChanOpenTry()
+	require.ErrorContains(t, err, "invalid IBC channel version. Got (counter-2), expected (counter-1)")
+}
@@ -0,0 +1,76 @@
+package simtests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	clienttypes "github.com/cosmos/ibc-go/v4/modules/core/02-client/types"
+	channeltypes "github.com/cosmos/ibc-go/v4/modules/core/04-channel/types"
+)
+
+// A packet that has been sent but not yet relayed, with its payload
+// decoded.
+type PendingPacket struct {
+	Sequence           uint64
+	SourcePort         string
+	SourceChannel      string
+	DestinationPort    string
+	DestinationChannel string
+	// Zero if the packet has no height timeout.
+	TimeoutHeight clienttypes.Height
+	// Zero if the packet has no timestamp timeout.
+	TimeoutTimestamp time.Time
+	// The payload of packets sent by contracts. Empty for packets
+	// sent by other modules, like ICS-20 transfers, whose payload
+	// is only in `Packet.Data`.
+	Msg    IbcExecuteMsg
+	Packet channeltypes.Packet
+}
+
+// Lists the packets waiting to be relayed from each end of the path,
+// in the order they were sent. Packets sent over other channels are
+// left out. Errors if the payload of a packet sent by a contract
+// isn't an `IbcExecuteMsg`.
+func PendingPackets(path *ibctesting.Path) (fromA, fromB []PendingPacket, err error) {
+	if fromA, err = pendingPackets(path.EndpointA); err != nil {
+		return nil, nil, err
+	}
+	if fromB, err = pendingPackets(path.EndpointB); err != nil {
+		return nil, nil, err
+	}
+	return fromA, fromB, nil
+}
+
+func pendingPackets(endpoint *ibctesting.Endpoint) ([]PendingPacket, error) {
+	var pending []PendingPacket
+	for _, packet := range endpoint.Chain.PendingSendPackets {
+		if packet.SourcePort != endpoint.ChannelConfig.PortID || packet.SourceChannel != endpoint.ChannelID {
+			continue
+		}
+		p := PendingPacket{
+			Sequence:           packet.Sequence,
+			SourcePort:         packet.SourcePort,
+			SourceChannel:      packet.SourceChannel,
+			DestinationPort:    packet.DestinationPort,
+			DestinationChannel: packet.DestinationChannel,
+			TimeoutHeight:      packet.TimeoutHeight,
+			Packet:             packet,
+		}
+		if packet.TimeoutTimestamp != 0 {
+			p.TimeoutTimestamp = time.Unix(0, int64(packet.TimeoutTimestamp)).UTC()
+		}
+		if strings.HasPrefix(packet.SourcePort, "wasm.") {
+			dec := json.NewDecoder(bytes.NewReader(packet.Data))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(&p.Msg); err != nil {
+				return nil, fmt.Errorf("decoding packet %d sent over %s: %w", packet.Sequence, packet.SourceChannel, err)
+			}
+		}
+		pending = append(pending, p)
+	}
+	return pending, nil
+}
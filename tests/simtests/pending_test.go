@@ -0,0 +1,80 @@
+package simtests
+
+import (
+	"errors"
+	"testing"
+
+	wasmkeeper "github.com/CosmWasm/wasmd/x/wasm/keeper"
+	wasmtypes "github.com/CosmWasm/wasmd/x/wasm/types"
+	wasmvm "github.com/CosmWasm/wasmvm"
+	wasmvmtypes "github.com/CosmWasm/wasmvm/types"
+	clienttypes "github.com/cosmos/ibc-go/v4/modules/core/02-client/types"
+	"github.com/stretchr/testify/require"
+	"withoutdoing.com/harness"
+)
+
+// Checks exactly what the contract sends when `execute`d: one
+// increment packet with a two minute timestamp timeout and no height
+// timeout.
+func TestPendingPackets(t *testing.T) {
+	f := SetupFixture(t)
+
+	sentAt := f.ChainA.CurrentHeader.Time
+	_, err := f.A.ExecuteIncrement(t, &f.ContractA, f.Path.EndpointA.ChannelID)
+	require.NoError(t, err)
+
+	fromA, fromB, err := PendingPackets(f.Path)
+	require.NoError(t, err)
+	require.Empty(t, fromB)
+	require.Len(t, fromA, 1)
+
+	p := fromA[0]
+	require.Equal(t, uint64(1), p.Sequence)
+	require.Equal(t, f.Path.EndpointA.ChannelConfig.PortID, p.SourcePort)
+	require.Equal(t, f.Path.EndpointA.ChannelID, p.SourceChannel)
+	require.Equal(t, f.Path.EndpointB.ChannelConfig.PortID, p.DestinationPort)
+	require.Equal(t, f.Path.EndpointB.ChannelID, p.DestinationChannel)
+	require.Equal(t, clienttypes.ZeroHeight(), p.TimeoutHeight)
+	require.Equal(t, sentAt.Add(DefaultTimeout), p.TimeoutTimestamp)
+	require.Equal(t, IbcExecuteMsg{Increment: &IbcIncrement{}}, p.Msg)
+
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+	fromA, fromB, err = PendingPackets(f.Path)
+	require.NoError(t, err)
+	require.Empty(t, fromA)
+	require.Empty(t, fromB)
+}
+
+// A wasm engine whose first packet acknowledgement fails.
+type failFirstAck struct {
+	wasmtypes.WasmerEngine
+	failed bool
+}
+
+func (e *failFirstAck) IBCPacketAck(checksum wasmvm.Checksum, env wasmvmtypes.Env, msg wasmvmtypes.IBCPacketAckMsg, store wasmvm.KVStore, goapi wasmvm.GoAPI, querier wasmvm.Querier, gasMeter wasmvm.GasMeter, gasLimit uint64, deserCost wasmvmtypes.UFraction) (*wasmvmtypes.IBCBasicResponse, uint64, error) {
+	if !e.failed {
+		e.failed = true
+		return nil, 0, errors.New("failing the first ack")
+	}
+	return e.WasmerEngine.IBCPacketAck(checksum, env, msg, store, goapi, querier, gasMeter, gasLimit, deserCost)
+}
+
+// Relaying packets whose acks failed to be relayed only relays the
+// acks again, as the packets have already been received.
+func TestRelayRetriesAcks(t *testing.T) {
+	engine := &failFirstAck{WasmerEngine: newVM(t, DefaultVMConfig())}
+	f := SetupFixture(t, WithWasmOptions(harness.A, wasmkeeper.WithWasmEngine(engine)))
+
+	_, err := f.A.ExecuteIncrement(t, &f.ContractA, f.Path.EndpointA.ChannelID)
+	require.NoError(t, err)
+	require.ErrorContains(t, RelayAndAckPendingPackets(f.Path), "acknowledging 1 packets")
+	f.RequireCount(t, f.CounterB(), f.Path.EndpointB.ChannelID, 1)
+
+	fromA, _, err := PendingPackets(f.Path)
+	require.NoError(t, err)
+	require.Len(t, fromA, 1)
+
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+	f.RequireCount(t, f.CounterB(), f.Path.EndpointB.ChannelID, 1)
+	f.RequireConverged(t)
+}
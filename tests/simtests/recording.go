@@ -0,0 +1,770 @@
+package simtests
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	channeltypes "github.com/cosmos/ibc-go/v4/modules/core/04-channel/types"
+	"withoutdoing.com/harness"
+)
+
+// Everything a test did to a fixture's chains, and where each thing
+// left them. Every fixture records one and writes it out when its
+// test finishes (see `NewHarness`), so a randomized or long test that
+// fails in CI can be run again locally with `ReplaySession` or
+// `ibcsim -replay`, against the same contract or another build of
+// it.
+//
+// `Session` steps are recorded as they are. Outside of steps, what's
+// recorded is every transaction sent with `Account.Send` or by a
+// chain's relayer (`RegisterPayee` for example), every call of a
+// helper in `relay.go`, every account made with `GenAccount`, every
+// packet a `Module` sends, and the `Harness` deploying and opening
+// its channel. The coordinator's clock moving on between those, with
+// `Coordinator.IncrementTimeBy` for example, is recorded as an
+// `advance_time` step. Anything else that moves a chain on, like
+// `Coordinator.CommitBlock`, `RestartFromGenesis` or `UpgradeChain`,
+// is recorded as something that can't be replayed, and replaying
+// stops there.
+//
+// Transactions are recorded as their messages, and relay helpers as
+// the path they ran on, rather than as signed transactions:
+// signatures and proofs only verify against the exact chain they
+// were made for, while the same messages from the same accounts do
+// the same thing to any chain that got there the same way.
+type Recording struct {
+	Name string `json:"name"`
+	// The seed the fixture's chains were made from.
+	Seed int64 `json:"seed"`
+	// The options the fixture was set up with.
+	Options RecordedOptions `json:"options"`
+	// The hex SHA-256 of the code deployed on chains `a` and `b`,
+	// or the Go type of a `Module`, like "*simtests.Peer".
+	Contracts map[string]string `json:"contracts,omitempty"`
+	Steps     []RecordedStep    `json:"steps"`
+}
+
+// The options a fixture was set up with. Wasm keeper options and
+// modules are Go values, so only the chains they were given for are
+// recorded, and replaying needs them to be passed again.
+type RecordedOptions struct {
+	// Whether the fixture's channels were fee enabled.
+	Fees bool `json:"fees,omitempty"`
+	// Whether the fixture opened a transfer channel.
+	Transfer bool `json:"transfer,omitempty"`
+	// The wasm files deployed on chains `a` and `b` in place of
+	// `WasmFile`.
+	WasmFiles map[string]string `json:"wasm_files,omitempty"`
+	// The chains given wasm keeper options, including `WithVM`.
+	WasmOptions []string `json:"wasm_options,omitempty"`
+	// The Go type of the `Module` run on chains `a` and `b`.
+	Modules map[string]string `json:"modules,omitempty"`
+}
+
+// Something a session or test did to the chains, and where it left
+// them. Exactly one of the fields before `Error` is set.
+type RecordedStep struct {
+	// A session step.
+	Step *Step `json:"step,omitempty"`
+	// A transaction.
+	Tx *RecordedTx `json:"tx,omitempty"`
+	// A packet sent by a `Module`.
+	Packet *RecordedPacket `json:"packet,omitempty"`
+	// A call of a helper in `relay.go`.
+	Relay *RecordedRelay `json:"relay,omitempty"`
+	// An account made with `GenAccount` on chain `a` or `b`.
+	Account string `json:"account,omitempty"`
+	// `Harness.OpenChannel`.
+	HarnessOpenChannel bool `json:"harness_open_channel,omitempty"`
+	// What changed the chains in a way that can't be replayed.
+	Unrecorded string `json:"unrecorded,omitempty"`
+
+	// The error the step failed with, if it did. Set whether or
+	// not the step expected it.
+	Error string `json:"error,omitempty"`
+	// The chains' shared clock and each chain's height after the
+	// step.
+	Time    time.Time        `json:"time"`
+	Heights map[string]int64 `json:"heights"`
+	// The counts on both ends of every channel a session opened so
+	// far.
+	Counts []RecordedCounts `json:"counts,omitempty"`
+}
+
+// A transaction's messages, and who sent them.
+type RecordedTx struct {
+	Chain string `json:"chain"`
+	// The sending account's address, or "" for the chain's relayer.
+	Sender string `json:"sender,omitempty"`
+	// The messages as JSON, with their type URLs.
+	Msgs []json.RawMessage `json:"msgs"`
+}
+
+// A packet sent with `ModuleEnv.SendPacket`.
+type RecordedPacket struct {
+	Chain   string        `json:"chain"`
+	Port    string        `json:"port"`
+	Channel string        `json:"channel"`
+	Data    []byte        `json:"data"`
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+// A call of a helper in `relay.go`.
+type RecordedRelay struct {
+	// The helper's name, like "RelayAndAckPendingPackets".
+	Helper string `json:"helper"`
+	// The path it ran on, as it was before. Helpers that take an
+	// endpoint ran on endpoint A.
+	Path RecordedPath `json:"path"`
+	// The packet, for `RelayPacket` and `TimeoutPacket`.
+	Packet *channeltypes.Packet `json:"packet,omitempty"`
+}
+
+type RecordedPath struct {
+	A RecordedEndpoint `json:"a"`
+	B RecordedEndpoint `json:"b"`
+}
+
+type RecordedEndpoint struct {
+	Chain        string `json:"chain"`
+	ClientID     string `json:"client_id,omitempty"`
+	ConnectionID string `json:"connection_id,omitempty"`
+	ChannelID    string `json:"channel_id,omitempty"`
+	Port         string `json:"port"`
+	Version      string `json:"version"`
+	Order        string `json:"order"`
+}
+
+// The counts of a chain's contract on one channel.
+type RecordedCounts struct {
+	Channel  string `json:"channel"`
+	On       string `json:"on"`
+	Count    uint32 `json:"count"`
+	Timeouts uint32 `json:"timeouts"`
+}
+
+// Reads a recording written by a fixture.
+func LoadRecording(path string) (Recording, error) {
+	var r Recording
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(bz, &r); err != nil {
+		return r, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Writes the recording as JSON.
+func (r Recording) WriteFile(path string) error {
+	bz, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(bz, '\n'), 0o644)
+}
+
+// The options to set up a fixture like the recorded one with,
+// followed by `opts`. Errors if the recorded fixture had wasm keeper
+// options or modules and `opts` doesn't give the same chains some,
+// or deployed a wasm file that isn't there and `opts` doesn't give
+// the chain another.
+func (r Recording) FixtureOptions(opts ...FixtureOption) ([]FixtureOption, error) {
+	given := newFixtureOptions(opts)
+	recorded := []FixtureOption{WithSeed(r.Seed)}
+	if r.Options.Fees {
+		recorded = append(recorded, WithFees())
+	}
+	if r.Options.Transfer {
+		recorded = append(recorded, WithTransferChannel())
+	}
+	wasmOptions := map[string]bool{}
+	for _, chain := range r.Options.WasmOptions {
+		wasmOptions[chain] = true
+	}
+	for _, side := range []harness.Side{harness.A, harness.B} {
+		chain := sideName(side)
+		if file, ok := r.Options.WasmFiles[chain]; ok && given.wasmFiles[side] == "" {
+			if _, err := os.Stat(file); err != nil {
+				return nil, fmt.Errorf("chain %s deployed %s, pass WithWasmFile to deploy something else: %w", chain, file, err)
+			}
+			recorded = append(recorded, WithWasmFile(side, file))
+		}
+		if wasmOptions[chain] && len(given.wasm[side]) == 0 {
+			return nil, fmt.Errorf("chain %s was set up WithWasmOptions, which can't be recorded, pass them again", chain)
+		}
+		if module, ok := r.Options.Modules[chain]; ok && given.modules[side] == nil {
+			return nil, fmt.Errorf("chain %s ran a %s, which can't be recorded, pass it again WithModule", chain, module)
+		}
+	}
+	return append(recorded, opts...), nil
+}
+
+// Runs a recording's steps on a new session, set up with the
+// recording's options (see `Recording.FixtureOptions`). The first
+// step that doesn't do what it did when it was recorded (see
+// `Session.Replay`) fails the test with a description of both
+// chains. `opts` can deploy something else than was recorded, for
+// example `WithWasmFile(harness.A, file)` to see whether another
+// build of the contract behaves the same.
+func ReplaySession(t *testing.T, r Recording, opts ...FixtureOption) *Session {
+	t.Helper()
+	opts, err := r.FixtureOptions(opts...)
+	if err != nil {
+		t.Fatalf("replaying %q: %s", r.Name, err)
+	}
+	s := NewSession(t, opts...)
+	for i, step := range r.Steps {
+		if err := s.Replay(step); err != nil {
+			action, _ := step.action()
+			t.Fatalf("step %d (%s) of %q: %s\n\n%s", i+1, action, r.Name, err, s.Fixture().Describe())
+		}
+	}
+	contracts := s.Recording().Contracts
+	for _, chain := range []string{"a", "b"} {
+		if got, want := contracts[chain], r.Contracts[chain]; got != want {
+			t.Logf("chain %s ran code %s, recorded with %s", chain, got, want)
+		}
+	}
+	return s
+}
+
+// The steps the session has run so far, along with everything else
+// recorded on its fixture.
+func (s *Session) Recording() Recording {
+	return s.h.Fixture.Recording()
+}
+
+// What has been recorded on the fixture so far.
+func (f *Fixture) Recording() Recording {
+	r := f.log.recording
+	r.Contracts = map[string]string{}
+	for chain, checksum := range f.log.recording.Contracts {
+		r.Contracts[chain] = checksum
+	}
+	r.Steps = append([]RecordedStep(nil), f.log.recording.Steps...)
+	return r
+}
+
+// Runs a recorded step, and errors if it fails when it succeeded
+// when recorded or the other way round, or leaves the chains at
+// different heights or time, or with different counts. Errors are
+// only compared by whether there was one, as they can name accounts.
+// Steps recorded as unrecorded can't be replayed, and error.
+func (s *Session) Replay(recorded RecordedStep) error {
+	if _, err := recorded.action(); err != nil {
+		return err
+	}
+	if recorded.Unrecorded != "" {
+		return fmt.Errorf("can't be replayed: %s", recorded.Unrecorded)
+	}
+	f := s.h.Fixture
+	_ = f.record(func(*Fixture) RecordedStep {
+		return recorded.withoutResults()
+	}, func() error {
+		return s.replay(recorded)
+	})
+	got := f.log.recording.Steps[len(f.log.recording.Steps)-1]
+	if problems := recorded.diff(got); len(problems) != 0 {
+		return fmt.Errorf("the session diverged from the recording: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (s *Session) replay(recorded RecordedStep) error {
+	switch {
+	case recorded.Step != nil:
+		return s.run(*recorded.Step)
+	case recorded.Tx != nil:
+		return s.replayTx(*recorded.Tx)
+	case recorded.Packet != nil:
+		p := recorded.Packet
+		chain, err := s.chain(p.Chain)
+		if err != nil {
+			return err
+		}
+		return ModuleEnv{Chain: chain}.SendPacket(p.Port, p.Channel, p.Data, p.Timeout)
+	case recorded.Relay != nil:
+		return s.replayRelay(*recorded.Relay)
+	case recorded.Account != "":
+		chain, err := s.chain(recorded.Account)
+		if err != nil {
+			return err
+		}
+		account := GenAccount(s.t, chain)
+		s.accounts[account.Address.String()] = &account
+		return nil
+	case recorded.HarnessOpenChannel:
+		return s.h.OpenChannel(context.Background())
+	}
+	return nil
+}
+
+func (s *Session) replayTx(tx RecordedTx) error {
+	chain, err := s.chain(tx.Chain)
+	if err != nil {
+		return err
+	}
+	msgs := make([]sdk.Msg, len(tx.Msgs))
+	for i, bz := range tx.Msgs {
+		if err := chain.App.AppCodec().UnmarshalInterfaceJSON(bz, &msgs[i]); err != nil {
+			return fmt.Errorf("message %d: %w", i+1, err)
+		}
+	}
+	if tx.Sender == "" {
+		_, err = deliver(chain, msgs...)
+		return err
+	}
+	account, err := s.account(tx.Sender)
+	if err != nil {
+		return err
+	}
+	_, err = account.Send(s.t, msgs...)
+	return err
+}
+
+func (s *Session) replayRelay(relay RecordedRelay) error {
+	path, err := s.recordedPath(relay.Path)
+	if err != nil {
+		return err
+	}
+	switch relay.Helper {
+	case "OpenChannel":
+		return OpenChannel(path)
+	case "CloseChannel":
+		return CloseChannel(path)
+	case "RelayAndAckPendingPackets":
+		return RelayAndAckPendingPackets(path)
+	case "TimeoutPendingPackets":
+		return TimeoutPendingPackets(path)
+	}
+	if relay.Packet == nil {
+		return fmt.Errorf("%s needs a packet", relay.Helper)
+	}
+	switch relay.Helper {
+	case "RelayPacket":
+		return RelayPacket(path.EndpointA, *relay.Packet)
+	case "TimeoutPacket":
+		return TimeoutPacket(path.EndpointA, *relay.Packet)
+	}
+	return fmt.Errorf("unknown relay helper %q", relay.Helper)
+}
+
+// A path with the recorded endpoints.
+func (s *Session) recordedPath(p RecordedPath) (*ibctesting.Path, error) {
+	a, err := s.chain(p.A.Chain)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.chain(p.B.Chain)
+	if err != nil {
+		return nil, err
+	}
+	path := ibctesting.NewPath(a, b)
+	for _, e := range []struct {
+		recorded RecordedEndpoint
+		endpoint *ibctesting.Endpoint
+	}{{p.A, path.EndpointA}, {p.B, path.EndpointB}} {
+		order, ok := channeltypes.Order_value[e.recorded.Order]
+		if !ok {
+			return nil, fmt.Errorf("unknown channel order %q", e.recorded.Order)
+		}
+		e.endpoint.ClientID = e.recorded.ClientID
+		e.endpoint.ConnectionID = e.recorded.ConnectionID
+		e.endpoint.ChannelID = e.recorded.ChannelID
+		e.endpoint.ChannelConfig.PortID = e.recorded.Port
+		e.endpoint.ChannelConfig.Version = e.recorded.Version
+		e.endpoint.ChannelConfig.Order = channeltypes.Order(order)
+	}
+	return path, nil
+}
+
+// The fixture's chain called `name`.
+func (s *Session) chain(name string) (*ibctesting.TestChain, error) {
+	f := s.h.Fixture
+	switch name {
+	case "a":
+		return f.ChainA, nil
+	case "b":
+		return f.ChainB, nil
+	}
+	return nil, fmt.Errorf("unknown chain %q, expected a or b", name)
+}
+
+// The fixture's account, or an account the session replayed making,
+// with `address`.
+func (s *Session) account(address string) (*Account, error) {
+	f := s.h.Fixture
+	for _, account := range []*Account{&f.A, &f.B} {
+		if account.Chain != nil && account.Address.String() == address {
+			return account, nil
+		}
+	}
+	if account, ok := s.accounts[address]; ok {
+		return account, nil
+	}
+	return nil, fmt.Errorf("no account %s has been made", address)
+}
+
+// The name of the step's action, or what kind of thing it recorded.
+// Errors unless exactly one is set.
+func (r RecordedStep) action() (string, error) {
+	var actions []string
+	set := func(name string, isSet bool) {
+		if isSet {
+			actions = append(actions, name)
+		}
+	}
+	if r.Step != nil {
+		action, err := r.Step.action()
+		if err != nil {
+			return "", err
+		}
+		actions = append(actions, action)
+	}
+	set("tx", r.Tx != nil)
+	set("packet", r.Packet != nil)
+	if r.Relay != nil {
+		actions = append(actions, r.Relay.Helper)
+	}
+	set("account", r.Account != "")
+	set("harness_open_channel", r.HarnessOpenChannel)
+	set("unrecorded", r.Unrecorded != "")
+	if len(actions) != 1 {
+		return "", fmt.Errorf("expected exactly one recorded action, got %d (%s)", len(actions), strings.Join(actions, ", "))
+	}
+	return actions[0], nil
+}
+
+// The step's action as JSON, like a scenario step for a session's
+// steps.
+func (r RecordedStep) Describe() string {
+	if r.Step != nil {
+		bz, _ := json.Marshal(r.Step)
+		return string(bz)
+	}
+	bz, _ := json.Marshal(r.withoutResults())
+	var fields map[string]json.RawMessage
+	_ = json.Unmarshal(bz, &fields)
+	delete(fields, "time")
+	delete(fields, "heights")
+	bz, _ = json.Marshal(fields)
+	return string(bz)
+}
+
+// The step without where it left the chains.
+func (r RecordedStep) withoutResults() RecordedStep {
+	return RecordedStep{
+		Step:               r.Step,
+		Tx:                 r.Tx,
+		Packet:             r.Packet,
+		Relay:              r.Relay,
+		Account:            r.Account,
+		HarnessOpenChannel: r.HarnessOpenChannel,
+		Unrecorded:         r.Unrecorded,
+	}
+}
+
+// How `got` differs from the recorded step.
+func (want RecordedStep) diff(got RecordedStep) []string {
+	var problems []string
+	switch {
+	case want.Error == "" && got.Error != "":
+		problems = append(problems, fmt.Sprintf("failed with %q, but succeeded when recorded", got.Error))
+	case want.Error != "" && got.Error == "":
+		problems = append(problems, fmt.Sprintf("succeeded, but failed with %q when recorded", want.Error))
+	}
+	for _, chain := range []string{"a", "b"} {
+		if got.Heights[chain] != want.Heights[chain] {
+			problems = append(problems, fmt.Sprintf("%s is at height %d, was at %d", chain, got.Heights[chain], want.Heights[chain]))
+		}
+	}
+	if !got.Time.Equal(want.Time) {
+		problems = append(problems, fmt.Sprintf("the time is %s, was %s", got.Time.UTC().Format(time.RFC3339), want.Time.UTC().Format(time.RFC3339)))
+	}
+	counts := map[RecordedCounts]bool{}
+	for _, c := range got.Counts {
+		counts[c] = true
+	}
+	for _, c := range want.Counts {
+		if !counts[c] {
+			problems = append(problems, fmt.Sprintf("%s on %s had count %d and timeouts %d, now %s", c.On, c.Channel, c.Count, c.Timeouts, got.countsOf(c.On, c.Channel)))
+		}
+	}
+	if len(got.Counts) > len(want.Counts) {
+		problems = append(problems, fmt.Sprintf("%d counts, %d recorded", len(got.Counts), len(want.Counts)))
+	}
+	return problems
+}
+
+func (s RecordedStep) countsOf(chain, channel string) string {
+	for _, c := range s.Counts {
+		if c.On == chain && c.Channel == channel {
+			return fmt.Sprintf("count %d and timeouts %d", c.Count, c.Timeouts)
+		}
+	}
+	return "can't query them"
+}
+
+// What a fixture has recorded so far.
+type actionLog struct {
+	recording Recording
+	// the number of recorded actions running, as only the outermost
+	// one is recorded.
+	depth int
+	// where the last recorded action left the chains.
+	time    time.Time
+	heights map[string]int64
+	// the counts to record after each action, set by a session.
+	counts func() []RecordedCounts
+}
+
+// The fixture of each coordinator, for `Account.Send`, the helpers in
+// `relay.go` and so on to record to.
+var fixtures = struct {
+	sync.Mutex
+	m map[*ibctesting.Coordinator]*Fixture
+}{m: map[*ibctesting.Coordinator]*Fixture{}}
+
+// Starts recording what's done to the fixture's chains, and writes
+// the recording out when the test finishes if anything was.
+func (f *Fixture) startRecording(t testing.TB) {
+	f.log = &actionLog{
+		recording: Recording{
+			Name:    t.Name(),
+			Seed:    f.Seed,
+			Options: recordOptions(f.opts),
+		},
+		time:    f.Coordinator.CurrentTime,
+		heights: f.heights(),
+	}
+	fixtures.Lock()
+	fixtures.m[f.Coordinator] = f
+	fixtures.Unlock()
+
+	t.Cleanup(func() {
+		fixtures.Lock()
+		delete(fixtures.m, f.Coordinator)
+		fixtures.Unlock()
+
+		if f.log.depth == 0 {
+			f.catchUp()
+		}
+		if len(f.log.recording.Steps) == 0 {
+			return
+		}
+		path := filepath.Join(timelineDir(), timelineFileName(t.Name(), ".session.json"))
+		if err := f.log.recording.WriteFile(path); err != nil {
+			t.Logf("writing session recording: %s", err)
+			return
+		}
+		t.Logf("session recording written to %s", path)
+	})
+}
+
+func recordOptions(o fixtureOptions) RecordedOptions {
+	r := RecordedOptions{Fees: o.fees, Transfer: o.transfer}
+	for _, side := range []harness.Side{harness.A, harness.B} {
+		chain := sideName(side)
+		if file := o.wasmFiles[side]; file != "" {
+			if r.WasmFiles == nil {
+				r.WasmFiles = map[string]string{}
+			}
+			r.WasmFiles[chain] = file
+		}
+		if len(o.wasm[side]) != 0 {
+			r.WasmOptions = append(r.WasmOptions, chain)
+		}
+		if module := o.modules[side]; module != nil {
+			if r.Modules == nil {
+				r.Modules = map[string]string{}
+			}
+			r.Modules[chain] = fmt.Sprintf("%T", module)
+		}
+	}
+	return r
+}
+
+// Runs `run` and records what `action` returns with where it left
+// the chains, if `chain` is a fixture's and `run` isn't part of
+// another recorded action.
+func record(chain *ibctesting.TestChain, action func(f *Fixture) RecordedStep, run func() error) error {
+	fixtures.Lock()
+	f := fixtures.m[chain.Coordinator]
+	fixtures.Unlock()
+	if f == nil {
+		return run()
+	}
+	return f.record(action, run)
+}
+
+func (f *Fixture) record(action func(f *Fixture) RecordedStep, run func() error) error {
+	l := f.log
+	if l.depth > 0 {
+		return run()
+	}
+	f.catchUp()
+	l.depth++
+	defer func() { l.depth-- }()
+	step := action(f)
+	err := run()
+	f.appendStep(step, err)
+	return err
+}
+
+// Records a transaction of `msgs` from `sender` on `chain`, which
+// `run` sends.
+func recordTxOf(chain *ibctesting.TestChain, sender string, msgs []sdk.Msg, run func() error) error {
+	return record(chain, func(f *Fixture) RecordedStep {
+		tx := RecordedTx{Chain: f.chainName(chain), Sender: sender}
+		for _, msg := range msgs {
+			bz, err := chain.App.AppCodec().MarshalInterfaceJSON(msg)
+			if err != nil {
+				return RecordedStep{Unrecorded: fmt.Sprintf("a transaction on %s with a %T, which can't be recorded: %s", tx.Chain, msg, err)}
+			}
+			tx.Msgs = append(tx.Msgs, bz)
+		}
+		return RecordedStep{Tx: &tx}
+	}, run)
+}
+
+// Records a call of a relay helper on the path from `endpoint` to
+// its counterparty.
+func recordRelay(helper string, endpoint *ibctesting.Endpoint, packet *channeltypes.Packet, run func() error) error {
+	return record(endpoint.Chain, func(f *Fixture) RecordedStep {
+		return RecordedStep{Relay: &RecordedRelay{
+			Helper: helper,
+			Path:   RecordedPath{A: f.recordEndpoint(endpoint), B: f.recordEndpoint(endpoint.Counterparty)},
+			Packet: packet,
+		}}
+	}, run)
+}
+
+// Runs `run`, which changes `chain` in a way that can't be replayed,
+// and records that it did.
+func recordUnreplayable(chain *ibctesting.TestChain, what string, run func()) {
+	_ = record(chain, func(f *Fixture) RecordedStep {
+		return RecordedStep{Unrecorded: fmt.Sprintf("%s on %s", what, f.chainName(chain))}
+	}, func() error {
+		run()
+		return nil
+	})
+}
+
+func (f *Fixture) recordEndpoint(endpoint *ibctesting.Endpoint) RecordedEndpoint {
+	return RecordedEndpoint{
+		Chain:        f.chainName(endpoint.Chain),
+		ClientID:     endpoint.ClientID,
+		ConnectionID: endpoint.ConnectionID,
+		ChannelID:    endpoint.ChannelID,
+		Port:         endpoint.ChannelConfig.PortID,
+		Version:      endpoint.ChannelConfig.Version,
+		Order:        endpoint.ChannelConfig.Order.String(),
+	}
+}
+
+// Records what happened to the chains since the last recorded
+// action: the clock moving on as an `advance_time` step, and chains
+// moving on as something that can't be replayed.
+func (f *Fixture) catchUp() {
+	l := f.log
+	heights := f.heights()
+	var moved []string
+	for _, chain := range []string{"a", "b"} {
+		if heights[chain] != l.heights[chain] {
+			moved = append(moved, fmt.Sprintf("%s from height %d to %d", chain, l.heights[chain], heights[chain]))
+		}
+	}
+	switch {
+	case len(moved) != 0:
+		f.appendStep(RecordedStep{Unrecorded: fmt.Sprintf("moved %s without anything recorded", strings.Join(moved, " and "))}, nil)
+	case !f.Coordinator.CurrentTime.Equal(l.time):
+		f.appendStep(RecordedStep{Step: &Step{AdvanceTime: f.Coordinator.CurrentTime.Sub(l.time).String()}}, nil)
+	}
+}
+
+// Appends a step, with where it left the chains, to the recording.
+func (f *Fixture) appendStep(step RecordedStep, err error) {
+	l := f.log
+	step.Time = f.Coordinator.CurrentTime
+	step.Heights = f.heights()
+	if err != nil {
+		step.Error = err.Error()
+	}
+	if l.counts != nil {
+		step.Counts = l.counts()
+	}
+	l.recording.Steps = append(l.recording.Steps, step)
+	l.time, l.heights = step.Time, step.Heights
+
+	for _, side := range []harness.Side{harness.A, harness.B} {
+		chain := sideName(side)
+		if _, ok := l.recording.Contracts[chain]; ok || !f.deployed(side) {
+			continue
+		}
+		if l.recording.Contracts == nil {
+			l.recording.Contracts = map[string]string{}
+		}
+		counter := f.CounterA()
+		if side == harness.B {
+			counter = f.CounterB()
+		}
+		if counter.Module != nil {
+			l.recording.Contracts[chain] = fmt.Sprintf("%T", counter.Module)
+			continue
+		}
+		info := counter.Chain.App.WasmKeeper.GetCodeInfo(counter.Chain.GetContext(), counter.Chain.ContractInfo(counter.Address).CodeID)
+		l.recording.Contracts[chain] = hex.EncodeToString(info.CodeHash)
+	}
+}
+
+func (f *Fixture) heights() map[string]int64 {
+	return map[string]int64{
+		"a": f.ChainA.CurrentHeader.Height,
+		"b": f.ChainB.CurrentHeader.Height,
+	}
+}
+
+// The name recordings give a fixture's chain.
+func (f *Fixture) chainName(chain *ibctesting.TestChain) string {
+	if chain == f.ChainB {
+		return "b"
+	}
+	return "a"
+}
+
+func sideName(side harness.Side) string {
+	return strings.ToLower(side.String())
+}
+
+// The counts on both ends of each channel the session opened, in
+// the order they were opened.
+func (s *Session) recordedCounts() []RecordedCounts {
+	var counts []RecordedCounts
+	for _, name := range s.names {
+		for _, chain := range []string{"a", "b"} {
+			count, timeouts, err := s.Counts(chain, name)
+			if err != nil {
+				// left out, so that replaying shows the
+				// difference if the query works there.
+				continue
+			}
+			counts = append(counts, RecordedCounts{Channel: name, On: chain, Count: count, Timeouts: timeouts})
+		}
+	}
+	return counts
+}
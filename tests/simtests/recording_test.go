@@ -0,0 +1,143 @@
+package simtests
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	"github.com/stretchr/testify/require"
+	"withoutdoing.com/harness"
+)
+
+// Runs the steps of a scenario file on a new session, and returns
+// its recording as read back from a file.
+func recordScenario(t *testing.T, file string) Recording {
+	s, err := LoadScenario(file)
+	require.NoError(t, err)
+	session := NewSession(t)
+	for _, step := range s.Steps {
+		require.NoError(t, session.Run(step))
+	}
+	path := filepath.Join(t.TempDir(), "session.json")
+	require.NoError(t, session.Recording().WriteFile(path))
+	r, err := LoadRecording(path)
+	require.NoError(t, err)
+	return r
+}
+
+func TestReplaySession(t *testing.T) {
+	r := recordScenario(t, "testdata/scenarios/timeout.yaml")
+	require.NotEmpty(t, r.Contracts["a"])
+
+	replayed := ReplaySession(t, r).Recording()
+	require.Equal(t, r.Contracts, replayed.Contracts)
+	require.Len(t, replayed.Steps, len(r.Steps))
+}
+
+func TestReplayDiverges(t *testing.T) {
+	r := recordScenario(t, "testdata/scenarios/counting.yaml")
+
+	// as if the contract had counted one more packet when recorded.
+	last := r.Steps[len(r.Steps)-1]
+	last.Counts = append([]RecordedCounts(nil), last.Counts...)
+	last.Counts[0].Count++
+	s := NewSession(t)
+	for _, step := range r.Steps[:len(r.Steps)-1] {
+		require.NoError(t, s.Replay(step))
+	}
+	require.ErrorContains(t, s.Replay(last), "had count")
+
+	// a peer is deployed without any transactions.
+	s = NewSession(t, WithPeer(harness.B, &Peer{}))
+	require.ErrorContains(t, s.Replay(r.Steps[0]), "b is at height")
+}
+
+// Transactions, relaying and the clock moving on outside of a
+// session's steps are recorded too.
+func TestReplayFixture(t *testing.T) {
+	f := SetupFixture(t, WithFees())
+	_, err := f.A.ExecuteIncrement(t, &f.ContractA, f.Path.EndpointA.ChannelID)
+	require.NoError(t, err)
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+	f.Coordinator.IncrementTimeBy(time.Minute)
+	_, err = f.B.ExecuteIncrement(t, &f.ContractB, f.Path.EndpointB.ChannelID)
+	require.NoError(t, err)
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+
+	r := f.Recording()
+	require.True(t, r.Options.Fees)
+	var actions []string
+	for _, step := range r.Steps {
+		action, err := step.action()
+		require.NoError(t, err)
+		actions = append(actions, action)
+	}
+	require.Equal(t, []string{"deploy", "harness_open_channel", "tx", "RelayAndAckPendingPackets", "advance_time", "tx", "RelayAndAckPendingPackets"}, actions)
+
+	replayed := ReplaySession(t, r).Fixture()
+	count, err := QueryCount(replayed.ChainA, replayed.ContractA, QueryMsg{GetCount: &GetCount{Channel: replayed.Path.EndpointA.ChannelID}})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, count)
+}
+
+// Options that can't be recorded have to be passed again to replay.
+func TestReplayNeedsOptions(t *testing.T) {
+	f := SetupFixture(t, WithPeer(harness.B, &Peer{}))
+	r := f.Recording()
+	_, err := r.FixtureOptions()
+	require.ErrorContains(t, err, "chain b ran a *simtests.Peer")
+	ReplaySession(t, r, WithPeer(harness.B, &Peer{}))
+
+	r.Options.Modules = nil
+	r.Options.WasmOptions = []string{"a"}
+	_, err = r.FixtureOptions()
+	require.ErrorContains(t, err, "chain a was set up WithWasmOptions")
+}
+
+// Runs random increments, relays, timeouts and clock advances on two
+// channels, then times out whatever is left and checks that the
+// contracts agree, and that the session replays. Relays and timeouts
+// fail when packets have timed out or haven't yet, which is fine, as
+// the packets stay pending. A failure leaves a recording of the
+// session to replay.
+func TestRandomSession(t *testing.T) {
+	rng := Rand(t)
+
+	s := NewSession(t)
+	channels := []string{"one", "two"}
+	run := func(text string) error {
+		step, err := ParseStep(text)
+		require.NoError(t, err)
+		return s.Run(step)
+	}
+	require.NoError(t, run("deploy: [a, b]"))
+	for _, channel := range channels {
+		require.NoError(t, run("open_channel: "+channel))
+	}
+	for i := 0; i < 20; i++ {
+		channel := channels[rng.Intn(len(channels))]
+		switch rng.Intn(5) {
+		case 0, 1:
+			require.NoError(t, run(fmt.Sprintf("increment: {on: %s, channel: %s}", []string{"a", "b"}[rng.Intn(2)], channel)))
+		case 2:
+			_ = run("relay: " + channel)
+		case 3:
+			_ = run("timeout: " + channel)
+		case 4:
+			require.NoError(t, run(fmt.Sprintf("advance_time: %ds", rng.Intn(60))))
+		}
+	}
+	require.NoError(t, run(fmt.Sprintf("advance_time: %s", DefaultTimeout+time.Minute)))
+	var paths []*ibctesting.Path
+	for _, channel := range channels {
+		require.NoError(t, run("timeout: "+channel))
+		path, err := s.Path(channel)
+		require.NoError(t, err)
+		paths = append(paths, path)
+	}
+	s.Fixture().RequireConverged(t, paths...)
+
+	ReplaySession(t, s.Recording())
+}
@@ -0,0 +1,421 @@
+package simtests
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	channeltypes "github.com/cosmos/ibc-go/v4/modules/core/04-channel/types"
+	host "github.com/cosmos/ibc-go/v4/modules/core/24-host"
+	sdkibctesting "github.com/cosmos/ibc-go/v4/testing"
+)
+
+// The helpers in this file do what the methods on
+// `ibctesting.Endpoint` do, but keep hold of the transaction results
+// so they can be recorded (see `timeline.go`), and work when more
+// than one packet is pending. A test's calls of them are recorded too,
+// so they can be replayed (see `recording.go`).
+
+// Opens a channel between the endpoints of a path, which must
+// already be connected. This is `Coordinator.CreateChannels`.
+func OpenChannel(path *ibctesting.Path) error {
+	return recordRelay("OpenChannel", path.EndpointA, nil, func() error {
+		return openChannel(path)
+	})
+}
+
+func openChannel(path *ibctesting.Path) error {
+	a, b := path.EndpointA, path.EndpointB
+
+	res, err := deliver(a.Chain, channeltypes.NewMsgChannelOpenInit(
+		a.ChannelConfig.PortID,
+		a.ChannelConfig.Version, a.ChannelConfig.Order, []string{a.ConnectionID},
+		b.ChannelConfig.PortID,
+		a.Chain.SenderAccount.GetAddress().String(),
+	))
+	if err != nil {
+		return fmt.Errorf("channel open init: %w", err)
+	}
+	if a.ChannelID, err = sdkibctesting.ParseChannelIDFromEvents(res.GetEvents()); err != nil {
+		return err
+	}
+	a.ChannelConfig.Version = a.GetChannel().Version
+
+	if err := b.UpdateClient(); err != nil {
+		return err
+	}
+	proof, height := a.Chain.QueryProof(host.ChannelKey(a.ChannelConfig.PortID, a.ChannelID))
+	res, err = deliver(b.Chain, channeltypes.NewMsgChannelOpenTry(
+		b.ChannelConfig.PortID,
+		b.ChannelConfig.Version, b.ChannelConfig.Order, []string{b.ConnectionID},
+		a.ChannelConfig.PortID, a.ChannelID, a.ChannelConfig.Version,
+		proof, height,
+		b.Chain.SenderAccount.GetAddress().String(),
+	))
+	if err != nil {
+		return fmt.Errorf("channel open try: %w", err)
+	}
+	if b.ChannelID, err = sdkibctesting.ParseChannelIDFromEvents(res.GetEvents()); err != nil {
+		return err
+	}
+	b.ChannelConfig.Version = b.GetChannel().Version
+
+	if err := a.UpdateClient(); err != nil {
+		return err
+	}
+	proof, height = b.Chain.QueryProof(host.ChannelKey(b.ChannelConfig.PortID, b.ChannelID))
+	if _, err := deliver(a.Chain, channeltypes.NewMsgChannelOpenAck(
+		a.ChannelConfig.PortID, a.ChannelID,
+		b.ChannelID, b.ChannelConfig.Version,
+		proof, height,
+		a.Chain.SenderAccount.GetAddress().String(),
+	)); err != nil {
+		return fmt.Errorf("channel open ack: %w", err)
+	}
+	a.ChannelConfig.Version = a.GetChannel().Version
+
+	if err := b.UpdateClient(); err != nil {
+		return err
+	}
+	proof, height = a.Chain.QueryProof(host.ChannelKey(a.ChannelConfig.PortID, a.ChannelID))
+	if _, err := deliver(b.Chain, channeltypes.NewMsgChannelOpenConfirm(
+		b.ChannelConfig.PortID, b.ChannelID,
+		proof, height,
+		b.Chain.SenderAccount.GetAddress().String(),
+	)); err != nil {
+		return fmt.Errorf("channel open confirm: %w", err)
+	}
+
+	// ensure counterparty is up to date
+	return a.UpdateClient()
+}
+
+// Closes the path's channel from endpoint A's side.
+func CloseChannel(path *ibctesting.Path) error {
+	return recordRelay("CloseChannel", path.EndpointA, nil, func() error {
+		return closeChannel(path)
+	})
+}
+
+func closeChannel(path *ibctesting.Path) error {
+	a, b := path.EndpointA, path.EndpointB
+
+	if _, err := deliver(a.Chain, channeltypes.NewMsgChannelCloseInit(
+		a.ChannelConfig.PortID, a.ChannelID,
+		a.Chain.SenderAccount.GetAddress().String(),
+	)); err != nil {
+		return fmt.Errorf("channel close init: %w", err)
+	}
+
+	if err := b.UpdateClient(); err != nil {
+		return err
+	}
+	proof, height := a.QueryProof(host.ChannelKey(a.ChannelConfig.PortID, a.ChannelID))
+	if _, err := deliver(b.Chain, channeltypes.NewMsgChannelCloseConfirm(
+		b.ChannelConfig.PortID, b.ChannelID,
+		proof, height,
+		b.Chain.SenderAccount.GetAddress().String(),
+	)); err != nil {
+		return fmt.Errorf("channel close confirm: %w", err)
+	}
+	return a.UpdateClient()
+}
+
+// Relays and acks all of the packets pending on either end of the
+// path.
+//
+// `Coordinator.RelayAndAckPendingPackets` removes packets from the
+// pending list while iterating over it, so it panics if more than
+// one packet is pending on a chain. This doesn't, and leaves packets
+// sent over other channels pending. Like a real relayer, it delivers
+// all of the packets pending on one end in a single transaction, and
+// then all of their acks in another. Each block moves the simulated
+// clock forward, so relaying packets one at a time would let later
+// packets time out while earlier ones are relayed.
+func RelayAndAckPendingPackets(path *ibctesting.Path) error {
+	return recordRelay("RelayAndAckPendingPackets", path.EndpointA, nil, func() error {
+		return relayAndAckPendingPackets(path)
+	})
+}
+
+func relayAndAckPendingPackets(path *ibctesting.Path) error {
+	for _, src := range []*ibctesting.Endpoint{path.EndpointA, path.EndpointB} {
+		packets, keep := takePending(src)
+		if len(packets) == 0 {
+			continue
+		}
+		if err := relayPackets(src, packets); err != nil {
+			src.Chain.PendingSendPackets = append(keep, packets...)
+			return err
+		}
+	}
+	return nil
+}
+
+// Relays packets sent from `src` to its counterparty in one
+// transaction, then relays their acknowledgements back in another.
+// If the packets are received but not acknowledged, their
+// acknowledgements are kept so that relaying them again only relays
+// the acknowledgements.
+func relayPackets(src *ibctesting.Endpoint, packets []channeltypes.Packet) error {
+	dst := src.Counterparty
+
+	acks := takeUnrelayedAcks(src, packets)
+	var receive []channeltypes.Packet
+	for _, packet := range packets {
+		if _, ok := acks[packet.Sequence]; !ok {
+			receive = append(receive, packet)
+		}
+	}
+	if len(receive) != 0 {
+		if err := dst.UpdateClient(); err != nil {
+			keepUnrelayedAcks(src, acks)
+			return err
+		}
+		relayer := dst.Chain.SenderAccount.GetAddress().String()
+		msgs := make([]sdk.Msg, len(receive))
+		for i, packet := range receive {
+			proof, height := src.Chain.QueryProof(host.PacketCommitmentKey(packet.SourcePort, packet.SourceChannel, packet.Sequence))
+			msgs[i] = channeltypes.NewMsgRecvPacket(packet, proof, height, relayer)
+		}
+		res, err := deliver(dst.Chain, msgs...)
+		if err != nil {
+			keepUnrelayedAcks(src, acks)
+			return fmt.Errorf("receiving %d packets: %w", len(receive), err)
+		}
+		for sequence, ack := range parseAcks(res.GetEvents()) {
+			acks[sequence] = ack
+		}
+	}
+
+	if err := src.UpdateClient(); err != nil {
+		keepUnrelayedAcks(src, acks)
+		return err
+	}
+	relayer := src.Chain.SenderAccount.GetAddress().String()
+	msgs := make([]sdk.Msg, len(packets))
+	for i, packet := range packets {
+		ack, ok := acks[packet.Sequence]
+		if !ok {
+			keepUnrelayedAcks(src, acks)
+			return fmt.Errorf("no acknowledgement written for packet %d", packet.Sequence)
+		}
+		proof, height := dst.QueryProof(host.PacketAcknowledgementKey(packet.DestinationPort, packet.DestinationChannel, packet.Sequence))
+		msgs[i] = channeltypes.NewMsgAcknowledgement(packet, ack, proof, height, relayer)
+	}
+	if _, err := deliver(src.Chain, msgs...); err != nil {
+		keepUnrelayedAcks(src, acks)
+		return fmt.Errorf("acknowledging %d packets: %w", len(packets), err)
+	}
+	return nil
+}
+
+// Acknowledgements written on the counterparty for packets sent over
+// a channel, by sequence, that `relayPackets` received but couldn't
+// relay back. Kept by channel rather than by endpoint, as a replayed
+// session relays over new endpoints for the same channels.
+var unrelayedAcks = struct {
+	sync.Mutex
+	m map[channelKey]map[uint64][]byte
+}{m: map[channelKey]map[uint64][]byte{}}
+
+// A channel on a chain.
+type channelKey struct {
+	chain         *ibctesting.TestChain
+	port, channel string
+}
+
+func channelKeyOf(endpoint *ibctesting.Endpoint) channelKey {
+	return channelKey{endpoint.Chain, endpoint.ChannelConfig.PortID, endpoint.ChannelID}
+}
+
+// Removes and returns the unrelayed acknowledgements of `packets`.
+func takeUnrelayedAcks(src *ibctesting.Endpoint, packets []channeltypes.Packet) map[uint64][]byte {
+	unrelayedAcks.Lock()
+	defer unrelayedAcks.Unlock()
+	acks := map[uint64][]byte{}
+	key := channelKeyOf(src)
+	kept := unrelayedAcks.m[key]
+	for _, packet := range packets {
+		if ack, ok := kept[packet.Sequence]; ok {
+			acks[packet.Sequence] = ack
+			delete(kept, packet.Sequence)
+		}
+	}
+	if len(kept) == 0 {
+		delete(unrelayedAcks.m, key)
+	}
+	return acks
+}
+
+func keepUnrelayedAcks(src *ibctesting.Endpoint, acks map[uint64][]byte) {
+	if len(acks) == 0 {
+		return
+	}
+	unrelayedAcks.Lock()
+	defer unrelayedAcks.Unlock()
+	key := channelKeyOf(src)
+	kept, ok := unrelayedAcks.m[key]
+	if !ok {
+		kept = map[uint64][]byte{}
+		unrelayedAcks.m[key] = kept
+	}
+	for sequence, ack := range acks {
+		kept[sequence] = ack
+	}
+}
+
+// The acknowledgements written in a transaction by packet sequence.
+// `ibctesting.ParseAckFromEvents` only finds the first one.
+func parseAcks(events sdk.Events) map[uint64][]byte {
+	acks := map[uint64][]byte{}
+	for _, event := range events {
+		if event.Type != channeltypes.EventTypeWriteAck {
+			continue
+		}
+		var sequence uint64
+		var ack []byte
+		for _, attr := range event.Attributes {
+			switch string(attr.Key) {
+			case channeltypes.AttributeKeySequence:
+				sequence, _ = strconv.ParseUint(string(attr.Value), 10, 64)
+			case channeltypes.AttributeKeyAck:
+				ack = attr.Value
+			}
+		}
+		acks[sequence] = ack
+	}
+	return acks
+}
+
+// Relays a packet sent from `src` to its counterparty and relays the
+// acknowledgement back.
+func RelayPacket(src *ibctesting.Endpoint, packet channeltypes.Packet) error {
+	return recordRelay("RelayPacket", src, &packet, func() error {
+		return relayPacket(src, packet)
+	})
+}
+
+func relayPacket(src *ibctesting.Endpoint, packet channeltypes.Packet) error {
+	dst := src.Counterparty
+
+	if err := dst.UpdateClient(); err != nil {
+		return err
+	}
+	proof, height := src.Chain.QueryProof(host.PacketCommitmentKey(packet.SourcePort, packet.SourceChannel, packet.Sequence))
+	res, err := deliver(dst.Chain, channeltypes.NewMsgRecvPacket(packet, proof, height, dst.Chain.SenderAccount.GetAddress().String()))
+	if err != nil {
+		return fmt.Errorf("receiving packet %d: %w", packet.Sequence, err)
+	}
+	ack, err := sdkibctesting.ParseAckFromEvents(res.GetEvents())
+	if err != nil {
+		return err
+	}
+
+	if err := src.UpdateClient(); err != nil {
+		return err
+	}
+	proof, height = dst.QueryProof(host.PacketAcknowledgementKey(packet.DestinationPort, packet.DestinationChannel, packet.Sequence))
+	if _, err := deliver(src.Chain, channeltypes.NewMsgAcknowledgement(packet, ack, proof, height, src.Chain.SenderAccount.GetAddress().String())); err != nil {
+		return fmt.Errorf("acknowledging packet %d: %w", packet.Sequence, err)
+	}
+	return nil
+}
+
+// Times out all of the packets pending on either end of the path.
+// The packets must have timed out on their destination chain, for
+// example after `Coordinator.IncrementTimeBy(DefaultTimeout)`.
+func TimeoutPendingPackets(path *ibctesting.Path) error {
+	return recordRelay("TimeoutPendingPackets", path.EndpointA, nil, func() error {
+		return timeoutPendingPackets(path)
+	})
+}
+
+func timeoutPendingPackets(path *ibctesting.Path) error {
+	// commit a block with the current time on both chains so the
+	// light clients see a time past the packets' timeouts.
+	path.EndpointA.Chain.Coordinator.CommitBlock(path.EndpointA.Chain, path.EndpointB.Chain)
+	return forEachPending(path, func(src *ibctesting.Endpoint, packet channeltypes.Packet) error {
+		return timeoutPacket(src, packet)
+	})
+}
+
+// Times out a packet sent from `src` which was never received.
+func TimeoutPacket(src *ibctesting.Endpoint, packet channeltypes.Packet) error {
+	return recordRelay("TimeoutPacket", src, &packet, func() error {
+		return timeoutPacket(src, packet)
+	})
+}
+
+func timeoutPacket(src *ibctesting.Endpoint, packet channeltypes.Packet) error {
+	dst := src.Counterparty
+
+	if err := src.UpdateClient(); err != nil {
+		return err
+	}
+	proof, height := dst.QueryProof(host.PacketReceiptKey(packet.DestinationPort, packet.DestinationChannel, packet.Sequence))
+	if _, err := deliver(src.Chain, channeltypes.NewMsgTimeout(packet, packet.Sequence, proof, height, src.Chain.SenderAccount.GetAddress().String())); err != nil {
+		return fmt.Errorf("timing out packet %d: %w", packet.Sequence, err)
+	}
+	return nil
+}
+
+// Calls `f` on each packet pending on either end of the path and
+// removes it from the pending list. Stops at the first error,
+// leaving the packet that caused it pending.
+func forEachPending(path *ibctesting.Path, f func(src *ibctesting.Endpoint, packet channeltypes.Packet) error) error {
+	for _, endpoint := range []*ibctesting.Endpoint{path.EndpointA, path.EndpointB} {
+		packets, keep := takePending(endpoint)
+		for i, packet := range packets {
+			if err := f(endpoint, packet); err != nil {
+				endpoint.Chain.PendingSendPackets = append(keep, packets[i:]...)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Removes the packets sent over the endpoint's channel from its
+// chain's pending list and returns them. `keep` is what's left on
+// the list.
+func takePending(endpoint *ibctesting.Endpoint) (packets, keep []channeltypes.Packet) {
+	for _, packet := range endpoint.Chain.PendingSendPackets {
+		if packet.SourcePort == endpoint.ChannelConfig.PortID && packet.SourceChannel == endpoint.ChannelID {
+			packets = append(packets, packet)
+		} else {
+			keep = append(keep, packet)
+		}
+	}
+	endpoint.Chain.PendingSendPackets = keep
+	return packets, keep
+}
+
+// Delivers messages from the chain's default sender and records the
+// transaction.
+func deliver(chain *ibctesting.TestChain, msgs ...sdk.Msg) (*sdk.Result, error) {
+	var res *sdk.Result
+	err := recordTxOf(chain, "", msgs, func() (err error) {
+		res, err = deliverFromRelayer(chain, msgs...)
+		return err
+	})
+	return res, err
+}
+
+func deliverFromRelayer(chain *ibctesting.TestChain, msgs ...sdk.Msg) (*sdk.Result, error) {
+	height, at := chain.CurrentHeader.Height, chain.Coordinator.CurrentTime
+	res, err := chain.SendMsgs(msgs...)
+	recordTx(chain, height, at, msgs, res, err)
+	if err != nil {
+		// a transaction that gets past the ante handler uses up
+		// the sender's sequence even if it fails, which
+		// `SendMsgs` doesn't account for.
+		acc := chain.App.AccountKeeper.GetAccount(chain.GetContext(), chain.SenderAccount.GetAddress())
+		if err := chain.SenderAccount.SetSequence(acc.GetSequence()); err != nil {
+			return res, err
+		}
+	}
+	return res, err
+}
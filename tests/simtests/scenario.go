@@ -0,0 +1,386 @@
+package simtests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	"gopkg.in/yaml.v3"
+	"withoutdoing.com/harness"
+)
+
+// A scenario read from a YAML or JSON file, for writing protocol
+// tests without writing Go. See `testdata/scenarios/` for examples.
+//
+// There are two chains, `a` and `b`. Channels are always between the
+// contracts on the two chains, and are named by the `open_channel`
+// step that opens them.
+type Scenario struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Steps       []Step `json:"steps" yaml:"steps"`
+}
+
+// One step of a scenario. Exactly one of the fields other than
+// `Error` must be set.
+type Step struct {
+	// Stores and instantiates the contract on the chains.
+	Deploy []string `json:"deploy,omitempty" yaml:"deploy,omitempty"`
+	// Opens a channel between the contracts and names it.
+	OpenChannel string `json:"open_channel,omitempty" yaml:"open_channel,omitempty"`
+	// Executes `increment` on a chain's contract.
+	Increment *ChannelStep `json:"increment,omitempty" yaml:"increment,omitempty"`
+	// Relays and acks the packets pending on a channel.
+	Relay string `json:"relay,omitempty" yaml:"relay,omitempty"`
+	// Moves both chains' clocks forward, for example "2m".
+	AdvanceTime string `json:"advance_time,omitempty" yaml:"advance_time,omitempty"`
+	// Times out the packets pending on a channel. Time must have
+	// been advanced past their timeouts.
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// Closes a channel from chain `a`'s side.
+	Close string `json:"close,omitempty" yaml:"close,omitempty"`
+	// Checks the count of packets a chain's contract received.
+	ExpectCount *ExpectStep `json:"expect_count,omitempty" yaml:"expect_count,omitempty"`
+	// Checks the count of a chain's contract's packets that timed
+	// out.
+	ExpectTimeoutCount *ExpectStep `json:"expect_timeout_count,omitempty" yaml:"expect_timeout_count,omitempty"`
+
+	// If set, the step must fail with an error containing this.
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// A chain and one of the channels opened by the scenario.
+type ChannelStep struct {
+	On      string `json:"on" yaml:"on"`
+	Channel string `json:"channel" yaml:"channel"`
+}
+
+type ExpectStep struct {
+	On      string `json:"on" yaml:"on"`
+	Channel string `json:"channel" yaml:"channel"`
+	Count   uint32 `json:"count" yaml:"count"`
+}
+
+// Reads a scenario from a `.yaml`, `.yml` or `.json` file. Unknown
+// fields are errors, so typos don't silently skip checks.
+func LoadScenario(path string) (Scenario, error) {
+	var s Scenario
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	switch filepath.Ext(path) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(bz))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&s)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(bz))
+		dec.KnownFields(true)
+		err = dec.Decode(&s)
+	default:
+		return s, fmt.Errorf("%s: expected a .yaml, .yml or .json file", path)
+	}
+	if err != nil {
+		return s, fmt.Errorf("%s: %w", path, err)
+	}
+	for i, step := range s.Steps {
+		if _, err := step.action(); err != nil {
+			return s, fmt.Errorf("%s: step %d: %w", path, i+1, err)
+		}
+	}
+	return s, nil
+}
+
+// Runs every scenario file matching `pattern` as a subtest named
+// after the file.
+func RunScenarioFiles(t *testing.T, pattern string) {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatalf("no scenario files match %s", pattern)
+	}
+	for _, path := range paths {
+		path := path
+		t.Run(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), func(t *testing.T) {
+			s, err := LoadScenario(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			RunScenario(t, s)
+		})
+	}
+}
+
+// Runs a scenario on new simulated chains. The first step that fails
+// fails the test with a description of both chains.
+func RunScenario(t *testing.T, s Scenario) {
+	t.Helper()
+	session := NewSession(t)
+	for i, step := range s.Steps {
+		if err := session.Run(step); err != nil {
+			action, _ := step.action()
+			t.Fatalf("step %d (%s) of %q: %s\n\n%s", i+1, action, s.Name, err, session.Fixture().Describe())
+		}
+	}
+}
+
+// Parses a single step written as YAML, for example
+// `relay: main` or `increment: {on: a, channel: main}`.
+func ParseStep(text string) (Step, error) {
+	var step Step
+	dec := yaml.NewDecoder(strings.NewReader(text))
+	dec.KnownFields(true)
+	if err := dec.Decode(&step); err != nil {
+		return step, err
+	}
+	_, err := step.action()
+	return step, err
+}
+
+// The name of the step's action. Errors unless exactly one action is
+// set.
+func (s Step) action() (string, error) {
+	var actions []string
+	set := func(name string, isSet bool) {
+		if isSet {
+			actions = append(actions, name)
+		}
+	}
+	set("deploy", len(s.Deploy) != 0)
+	set("open_channel", s.OpenChannel != "")
+	set("increment", s.Increment != nil)
+	set("relay", s.Relay != "")
+	set("advance_time", s.AdvanceTime != "")
+	set("timeout", s.Timeout != "")
+	set("close", s.Close != "")
+	set("expect_count", s.ExpectCount != nil)
+	set("expect_timeout_count", s.ExpectTimeoutCount != nil)
+	if len(actions) != 1 {
+		return "", fmt.Errorf("expected exactly one action, got %d (%s)", len(actions), strings.Join(actions, ", "))
+	}
+	return actions[0], nil
+}
+
+// Runs scenario steps one at a time on two new simulated chains.
+// `RunScenario` runs a whole scenario with one, and `cmd/ibcsim` runs
+// steps from the terminal.
+type Session struct {
+	t        *testing.T
+	h        *Harness
+	deployed map[string]bool
+	channels map[string]*ibctesting.Path
+	// channel names in the order they were opened.
+	names []string
+	// accounts made by replayed steps, by address.
+	accounts map[string]*Account
+}
+
+// Starts a session with nothing deployed on its chains. Like any
+// harness's, what the session does is recorded and written out when
+// the test finishes (see `NewHarness`), along with the counts on
+// every channel it opened after each step, for `ReplaySession` to
+// run again.
+func NewSession(t *testing.T, opts ...FixtureOption) *Session {
+	h := NewHarness(t, opts...)
+	s := &Session{
+		t:        t,
+		h:        h,
+		deployed: map[string]bool{},
+		channels: map[string]*ibctesting.Path{},
+		accounts: map[string]*Account{},
+	}
+	h.Fixture.log.counts = s.recordedCounts
+	return s
+}
+
+// The fixture the session's steps are run on. `Fixture.Path` is the
+// first channel opened.
+func (s *Session) Fixture() *Fixture {
+	return s.h.Fixture
+}
+
+// The names of the channels opened so far, in the order they were
+// opened.
+func (s *Session) Channels() []string {
+	return append([]string(nil), s.names...)
+}
+
+// The path of a channel opened by the session.
+func (s *Session) Path(channel string) (*ibctesting.Path, error) {
+	path, ok := s.channels[channel]
+	if !ok {
+		return nil, fmt.Errorf("no channel named %q has been opened", channel)
+	}
+	return path, nil
+}
+
+// The number of packets the contract on `chain` has received over
+// `channel`, and the number it sent that timed out.
+func (s *Session) Counts(chain, channel string) (count, timeouts uint32, err error) {
+	_, contract, endpoint, err := s.side(chain, channel)
+	if err != nil {
+		return 0, 0, err
+	}
+	return contract.Counts(endpoint.ChannelID)
+}
+
+// Runs a step. If the step has an `Error`, it is an error for the
+// step to succeed or to fail with a different error.
+func (s *Session) Run(step Step) error {
+	if _, err := step.action(); err != nil {
+		return err
+	}
+	err := s.h.Fixture.record(func(*Fixture) RecordedStep {
+		return RecordedStep{Step: &step}
+	}, func() error {
+		return s.run(step)
+	})
+	switch {
+	case step.Error == "":
+		return err
+	case err == nil:
+		return fmt.Errorf("expected an error containing %q", step.Error)
+	case !strings.Contains(err.Error(), step.Error):
+		return fmt.Errorf("expected an error containing %q, got: %w", step.Error, err)
+	}
+	return nil
+}
+
+func (s *Session) run(step Step) error {
+	f := s.h.Fixture
+	switch {
+	case len(step.Deploy) != 0:
+		for _, name := range step.Deploy {
+			if err := s.deploy(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	case step.OpenChannel != "":
+		return s.openChannel(step.OpenChannel)
+	case step.Increment != nil:
+		account, contract, endpoint, err := s.side(step.Increment.On, step.Increment.Channel)
+		if err != nil {
+			return err
+		}
+		return contract.increment(s.t, account, endpoint.ChannelID)
+	case step.Relay != "":
+		return s.withPath(step.Relay, RelayAndAckPendingPackets)
+	case step.AdvanceTime != "":
+		d, err := time.ParseDuration(step.AdvanceTime)
+		if err != nil {
+			return err
+		}
+		f.Coordinator.IncrementTimeBy(d)
+		return nil
+	case step.Timeout != "":
+		return s.withPath(step.Timeout, TimeoutPendingPackets)
+	case step.Close != "":
+		return s.withPath(step.Close, CloseChannel)
+	case step.ExpectCount != nil:
+		return s.expect(*step.ExpectCount, "count", false)
+	case step.ExpectTimeoutCount != nil:
+		return s.expect(*step.ExpectTimeoutCount, "timeout count", true)
+	}
+	return nil
+}
+
+func (s *Session) deploy(name string) error {
+	f := s.h.Fixture
+	if s.deployed[name] {
+		return fmt.Errorf("already deployed on %s", name)
+	}
+	var err error
+	switch name {
+	case "a":
+		err = f.deploy(s.t, harness.A)
+	case "b":
+		err = f.deploy(s.t, harness.B)
+	default:
+		return fmt.Errorf("unknown chain %q, expected a or b", name)
+	}
+	if err != nil {
+		return err
+	}
+	s.deployed[name] = true
+	return nil
+}
+
+// Opens a channel. The first channel sets up the clients and the
+// connection, later ones reuse them.
+func (s *Session) openChannel(name string) error {
+	f := s.h.Fixture
+	if _, ok := s.channels[name]; ok {
+		return fmt.Errorf("channel %q is already open", name)
+	}
+	if !s.deployed["a"] || !s.deployed["b"] {
+		return fmt.Errorf("the contract must be deployed on both chains first")
+	}
+	path := ibctesting.NewPath(f.ChainA, f.ChainB)
+	path.EndpointA.ChannelConfig = ChannelConfig(f.CounterA().Port(), s.h.opts...)
+	path.EndpointB.ChannelConfig = ChannelConfig(f.CounterB().Port(), s.h.opts...)
+	if f.Path == nil {
+		f.Coordinator.SetupConnections(path)
+		f.Path = path
+	} else {
+		path.EndpointA.ClientID, path.EndpointA.ConnectionID = f.Path.EndpointA.ClientID, f.Path.EndpointA.ConnectionID
+		path.EndpointB.ClientID, path.EndpointB.ConnectionID = f.Path.EndpointB.ClientID, f.Path.EndpointB.ConnectionID
+	}
+	if err := OpenChannel(path); err != nil {
+		return err
+	}
+	s.channels[name] = path
+	s.names = append(s.names, name)
+	return nil
+}
+
+func (s *Session) withPath(channel string, f func(*ibctesting.Path) error) error {
+	path, err := s.Path(channel)
+	if err != nil {
+		return err
+	}
+	return f(path)
+}
+
+func (s *Session) expect(e ExpectStep, what string, timeouts bool) error {
+	_, contract, endpoint, err := s.side(e.On, e.Channel)
+	if err != nil {
+		return err
+	}
+	got, timeoutCount, err := contract.Counts(endpoint.ChannelID)
+	if err != nil {
+		return err
+	}
+	if timeouts {
+		got = timeoutCount
+	}
+	if got != e.Count {
+		return fmt.Errorf("expected %s %d on %s for %s (%s), got %d", what, e.Count, e.On, e.Channel, endpoint.ChannelID, got)
+	}
+	return nil
+}
+
+// The account, contract, and channel endpoint of a chain.
+func (s *Session) side(chain, channel string) (*Account, Contract, *ibctesting.Endpoint, error) {
+	path, err := s.Path(channel)
+	if err != nil {
+		return nil, Contract{}, nil, err
+	}
+	f := s.h.Fixture
+	switch chain {
+	case "a":
+		return &f.A, f.CounterA(), path.EndpointA, nil
+	case "b":
+		return &f.B, f.CounterB(), path.EndpointB, nil
+	}
+	return nil, Contract{}, nil, fmt.Errorf("unknown chain %q, expected a or b", chain)
+}
@@ -0,0 +1,40 @@
+package simtests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScenarioFiles(t *testing.T) {
+	RunScenarioFiles(t, "testdata/scenarios/*")
+}
+
+func TestLoadScenarioRejectsBadSteps(t *testing.T) {
+	dir := t.TempDir()
+	for name, contents := range map[string]string{
+		"typo.yaml":   "steps:\n  - incremnt: {on: a, channel: main}\n",
+		"two.yaml":    "steps:\n  - relay: main\n    close: main\n",
+		"none.json":   `{"steps": [{"error": "x"}]}`,
+		"typo.json":   `{"steps": [{"relay": "main", "extra": 1}]}`,
+		"scenario.md": "",
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+		_, err := LoadScenario(path)
+		require.Error(t, err, name)
+	}
+}
+
+func TestParseStep(t *testing.T) {
+	step, err := ParseStep("increment: {on: a, channel: main}")
+	require.NoError(t, err)
+	require.Equal(t, Step{Increment: &ChannelStep{On: "a", Channel: "main"}}, step)
+
+	_, err = ParseStep("relay: main, close: main")
+	require.Error(t, err)
+	_, err = ParseStep("{relay: main, close: main}")
+	require.Error(t, err)
+}
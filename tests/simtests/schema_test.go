@@ -0,0 +1,19 @@
+package simtests
+
+import (
+	"testing"
+
+	"withoutdoing.com/harness/msgschema"
+)
+
+// Where `cargo schema` writes the contract's JSON schema.
+const schemaDir = "../../schema/raw/"
+
+func TestMessagesMatchSchema(t *testing.T) {
+	msgschema.Require(t, schemaDir+"instantiate.json", InstantiateMsg{})
+	msgschema.Require(t, schemaDir+"execute.json", ExecuteMsg{})
+	msgschema.Require(t, schemaDir+"query.json", QueryMsg{})
+	msgschema.Require(t, schemaDir+"response_to_get_count.json", GetCountResponse{Count: 1})
+	msgschema.Require(t, schemaDir+"response_to_get_timeout_count.json", GetCountResponse{Count: 1})
+	msgschema.Require(t, schemaDir+"ibc_execute_msg.json", IbcExecuteMsg{})
+}
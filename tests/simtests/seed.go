@@ -0,0 +1,226 @@
+package simtests
+
+import (
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CosmWasm/wasmd/app"
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	wasmkeeper "github.com/CosmWasm/wasmd/x/wasm/keeper"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/cosmos/ibc-go/v4/testing/mock"
+	"github.com/stretchr/testify/require"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// Everything random about a fixture comes from a seed: the chains'
+// validator and relayer keys, and the keys of accounts made with
+// `GenAccount`. So do the choices of randomized tests that use
+// `Rand`.
+//
+// A run picks a base seed, and each test derives its own from it and
+// its name, so one test run on its own with the same base seed does
+// exactly what it did as part of the whole run. Tests that fail
+// print the base seed.
+var seedFlag = flag.String("simtests.seed", "", "the base seed of simulated chains and randomized tests, also read from $"+seedEnv+" (default a new one each run)")
+
+const seedEnv = "SIMTESTS_SEED"
+
+var base struct {
+	once sync.Once
+	seed int64
+	err  error
+}
+
+// The run's base seed: `-simtests.seed`, or `$SIMTESTS_SEED`, or the
+// time.
+func baseSeed() (int64, error) {
+	base.once.Do(func() {
+		text, from := *seedFlag, "-simtests.seed"
+		if text == "" {
+			text, from = os.Getenv(seedEnv), "$"+seedEnv
+		}
+		if text == "" {
+			base.seed = time.Now().UnixNano()
+			return
+		}
+		if base.seed, base.err = strconv.ParseInt(text, 10, 64); base.err != nil {
+			base.err = fmt.Errorf("%s: %w", from, base.err)
+		}
+	})
+	return base.seed, base.err
+}
+
+type testSeed struct {
+	seed int64
+	rng  *rand.Rand
+}
+
+var testSeeds = struct {
+	sync.Mutex
+	m map[testing.TB]*testSeed
+}{m: map[testing.TB]*testSeed{}}
+
+// The test's seed, derived from the run's base seed and the test's
+// name. If the test fails, the base seed is printed along with how
+// to run it again with it.
+func Seed(t testing.TB) int64 {
+	return seedOf(t).seed
+}
+
+// A source of random choices for randomized tests, seeded from the
+// test's seed. Every call in a test returns the same source.
+func Rand(t testing.TB) *rand.Rand {
+	return seedOf(t).rng
+}
+
+func seedOf(t testing.TB) *testSeed {
+	testSeeds.Lock()
+	defer testSeeds.Unlock()
+	if s, ok := testSeeds.m[t]; ok {
+		return s
+	}
+	b, err := baseSeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed := derive(b, t.Name())
+	s := &testSeed{seed: seed, rng: rand.New(rand.NewSource(derive(seed, "choices")))}
+	testSeeds.m[t] = s
+	t.Cleanup(func() {
+		testSeeds.Lock()
+		delete(testSeeds.m, t)
+		testSeeds.Unlock()
+		if t.Failed() {
+			t.Logf("simtests base seed %d, run again with -simtests.seed=%d or %s=%d", b, b, seedEnv, b)
+		}
+	})
+	return s
+}
+
+// A seed for one use of `seed`, so that using one of them more or
+// less doesn't change the others.
+func derive(seed int64, use string) int64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d/%s", seed, use)
+	return int64(h.Sum64())
+}
+
+// The sources of account keys of seeded coordinators.
+var accountKeys = struct {
+	sync.Mutex
+	m map[*ibctesting.Coordinator]*rand.Rand
+}{m: map[*ibctesting.Coordinator]*rand.Rand{}}
+
+// How many validators each chain has, as with `ibctesting.NewTestChain`.
+const validatorsPerChain = 4
+
+// Creates two chains whose validator and sender keys come from
+// `seed`. They start at ibctesting's start time, like any others.
+func newSeededCoordinator(tb testing.TB, seed int64, opts ...[]wasmkeeper.Option) *ibctesting.Coordinator {
+	t := chainT(tb)
+	c := ibctesting.NewCoordinator(t, 0)
+	keys := rand.New(rand.NewSource(derive(seed, "chains")))
+	for i := 0; i < 2; i++ {
+		var o []wasmkeeper.Option
+		if len(opts) > i {
+			o = opts[i]
+		}
+		chainID := ibctesting.GetChainID(i)
+		c.Chains[chainID] = newSeededChain(t, c, chainID, keys, o...)
+	}
+
+	accountKeys.Lock()
+	accountKeys.m[c] = rand.New(rand.NewSource(derive(seed, "accounts")))
+	accountKeys.Unlock()
+	tb.Cleanup(func() {
+		accountKeys.Lock()
+		delete(accountKeys.m, c)
+		accountKeys.Unlock()
+	})
+	return c
+}
+
+// Like `ibctesting.NewTestChain`, but with keys from `keys`.
+//
+// `ibctesting.NewTestChainWithValSet` takes the validators, but makes
+// its sender accounts with `crypto/rand`, so their genesis is
+// replaced by one with seeded senders and the chain's first block is
+// committed again on a new app.
+func newSeededChain(t *testing.T, c *ibctesting.Coordinator, chainID string, keys *rand.Rand, opts ...wasmkeeper.Option) *ibctesting.TestChain {
+	validators := make([]*tmtypes.Validator, 0, validatorsPerChain)
+	signers := make(map[string]tmtypes.PrivValidator, validatorsPerChain)
+	for i := 0; i < validatorsPerChain; i++ {
+		pv := mock.PV{PrivKey: ed25519.GenPrivKeyFromSecret(secret(keys))}
+		pubKey, err := pv.GetPubKey()
+		require.NoError(t, err)
+		validators = append(validators, tmtypes.NewValidator(pubKey, 1))
+		signers[pubKey.Address().String()] = pv
+	}
+	valSet := tmtypes.NewValidatorSet(validators)
+
+	start := c.CurrentTime
+	chain := ibctesting.NewTestChainWithValSet(t, c, chainID, valSet, signers, opts...)
+	c.CurrentTime = start
+
+	amount, ok := sdk.NewIntFromString("10000000000000000000")
+	require.True(t, ok)
+	var (
+		accounts []authtypes.GenesisAccount
+		balances []banktypes.Balance
+		senders  []ibctesting.SenderAccount
+	)
+	for i := 0; i < ibctesting.MaxAccounts; i++ {
+		key := secp256k1.GenPrivKeyFromSecret(secret(keys))
+		account := authtypes.NewBaseAccount(key.PubKey().Address().Bytes(), key.PubKey(), uint64(i), 0)
+		accounts = append(accounts, account)
+		balances = append(balances, banktypes.Balance{
+			Address: account.GetAddress().String(),
+			Coins:   sdk.NewCoins(sdk.NewCoin(sdk.DefaultBondDenom, amount)),
+		})
+		senders = append(senders, ibctesting.SenderAccount{SenderAccount: account, SenderPrivKey: key})
+	}
+
+	restarted := app.SetupWithGenesisValSet(t, valSet, accounts, chainID, opts, balances...)
+	chain.App = restarted
+	chain.QueryServer = restarted.IBCKeeper
+	chain.Codec = restarted.AppCodec()
+	chain.SenderAccounts = senders
+	chain.SenderAccount = senders[0].SenderAccount
+	chain.SenderPrivKey = senders[0].SenderPrivKey
+	chain.CurrentHeader = tmproto.Header{ChainID: chainID, Height: 1, Time: c.CurrentTime.UTC()}
+	c.CommitBlock(chain)
+	return chain
+}
+
+// 32 bytes to make a key from.
+func secret(keys *rand.Rand) []byte {
+	b := make([]byte, 32)
+	keys.Read(b)
+	return b
+}
+
+// A key for a new account on `chain`, from its coordinator's seed if
+// it has one.
+func genPrivKey(chain *ibctesting.TestChain) cryptotypes.PrivKey {
+	accountKeys.Lock()
+	defer accountKeys.Unlock()
+	rng, ok := accountKeys.m[chain.Coordinator]
+	if !ok {
+		return secp256k1.GenPrivKey()
+	}
+	return secp256k1.GenPrivKeyFromSecret(secret(rng))
+}
@@ -0,0 +1,50 @@
+package simtests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Sets up a fixture from `seed` and relays an increment over it.
+func seededRun(t *testing.T, seed int64) *Fixture {
+	f := SetupFixture(t, WithSeed(seed))
+	_, err := f.A.ExecuteIncrement(t, &f.ContractA, f.Path.EndpointA.ChannelID)
+	require.NoError(t, err)
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+	return f
+}
+
+func TestSeededChainsAreIdentical(t *testing.T) {
+	first, second := seededRun(t, 42), seededRun(t, 42)
+
+	require.Equal(t, first.A.Address, second.A.Address)
+	require.Equal(t, first.ChainB.SenderAccount.GetAddress(), second.ChainB.SenderAccount.GetAddress())
+	require.Equal(t, first.ChainA.Vals.Hash(), second.ChainA.Vals.Hash())
+	require.Equal(t, first.Coordinator.CurrentTime, second.Coordinator.CurrentTime)
+	require.Equal(t, first.ChainA.App.LastCommitID(), second.ChainA.App.LastCommitID())
+	require.Equal(t, first.ChainB.App.LastCommitID(), second.ChainB.App.LastCommitID())
+	require.Equal(t, first.Recorder.Timeline(), second.Recorder.Timeline())
+}
+
+func TestSeedsDiffer(t *testing.T) {
+	first, second := seededRun(t, 1), seededRun(t, 2)
+
+	require.NotEqual(t, first.A.Address, second.A.Address)
+	require.NotEqual(t, first.ChainA.Vals.Hash(), second.ChainA.Vals.Hash())
+	require.NotEqual(t, first.ChainB.SenderAccount.GetAddress(), second.ChainB.SenderAccount.GetAddress())
+	// the chains start at the same time whatever the seed.
+	require.Equal(t, first.Coordinator.CurrentTime, second.Coordinator.CurrentTime)
+	require.NotEqual(t, first.ChainA.App.LastCommitID(), second.ChainA.App.LastCommitID())
+}
+
+func TestSeedIsPerTest(t *testing.T) {
+	seed := Seed(t)
+	require.Equal(t, seed, Seed(t))
+	require.Equal(t, seed, SetupFixture(t).Seed)
+	require.Same(t, Rand(t), Rand(t))
+
+	t.Run("subtest", func(t *testing.T) {
+		require.NotEqual(t, seed, Seed(t))
+	})
+}
@@ -0,0 +1,227 @@
+package simtests
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// A port on a chain, which is a participant in a sequence diagram.
+type diagramPort struct {
+	chain string
+	port  string
+}
+
+// One end of a channel.
+type diagramEndpoint struct {
+	diagramPort
+	channel string
+}
+
+// Renders the IBC events in a timeline as a Mermaid
+// (https://mermaid.js.org) sequence diagram between the ports on each
+// chain. Handshake and close steps are drawn as arrows from the chain
+// the step happened on to its counterparty, the same way the README
+// describes them. Sends are drawn as notes, and receives, acks and
+// timeouts as arrows labeled with the packet's sequence and channels.
+// Acks are labeled with the acknowledgement the receiving contract
+// wrote.
+func SequenceDiagram(timeline []TimelineEntry) string {
+	d := newDiagram(timeline)
+
+	var body strings.Builder
+	line := func(format string, args ...any) {
+		fmt.Fprintf(&body, "    "+format+"\n", args...)
+	}
+	for _, e := range timeline {
+		if e.Kind != KindIBC {
+			continue
+		}
+		a := e.Attributes
+		switch e.Type {
+		case "channel_open_init", "channel_open_try", "channel_open_ack", "channel_open_confirm",
+			"channel_close_init", "channel_close_confirm":
+			from := diagramEndpoint{diagramPort{e.Chain, a["port_id"]}, a["channel_id"]}
+			to := d.counterparty(from, a["counterparty_port_id"])
+			line("%s->>%s: %s %s", d.id(from.diagramPort), d.id(to.diagramPort), handshakeStep(e.Type), from.channel)
+		case "send_packet":
+			from := diagramPort{e.Chain, a["packet_src_port"]}
+			line("Note over %s: send seq %s on %s", d.id(from), a["packet_sequence"], a["packet_src_channel"])
+		case "recv_packet":
+			to := diagramEndpoint{diagramPort{e.Chain, a["packet_dst_port"]}, a["packet_dst_channel"]}
+			from := d.counterparty(to, a["packet_src_port"])
+			line("%s->>%s: recv seq %s %s→%s", d.id(from.diagramPort), d.id(to.diagramPort), a["packet_sequence"], a["packet_src_channel"], a["packet_dst_channel"])
+		case "acknowledge_packet":
+			to := diagramEndpoint{diagramPort{e.Chain, a["packet_src_port"]}, a["packet_src_channel"]}
+			from := d.counterparty(to, a["packet_dst_port"])
+			label := fmt.Sprintf("ack seq %s %s→%s", a["packet_sequence"], a["packet_dst_channel"], a["packet_src_channel"])
+			if ack, ok := d.acks[packetKey(from.chain, a)]; ok {
+				label += ": " + ackResult(ack)
+			}
+			line("%s-->>%s: %s", d.id(from.diagramPort), d.id(to.diagramPort), mermaidText(label))
+		case "timeout_packet":
+			to := diagramEndpoint{diagramPort{e.Chain, a["packet_src_port"]}, a["packet_src_channel"]}
+			from := d.counterparty(to, a["packet_dst_port"])
+			line("%s--x%s: timeout seq %s %s→%s", d.id(from.diagramPort), d.id(to.diagramPort), a["packet_sequence"], a["packet_dst_channel"], a["packet_src_channel"])
+		}
+	}
+
+	var out strings.Builder
+	out.WriteString("sequenceDiagram\n")
+	for _, p := range d.participants() {
+		fmt.Fprintf(&out, "    participant %s as %s<br/>%s\n", d.id(p), p.chain, p.port)
+	}
+	out.WriteString(body.String())
+	return out.String()
+}
+
+// Writes the recorded timeline as a Mermaid sequence diagram.
+func (r *Recorder) WriteSequenceDiagram(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(SequenceDiagram(r.Timeline())), 0o644)
+}
+
+type diagram struct {
+	chains []string
+	ids    map[diagramPort]string
+	// both directions of every channel opened in the timeline.
+	channels map[diagramEndpoint]diagramEndpoint
+	// acknowledgements written by receiving chains, keyed by
+	// `packetKey`.
+	acks map[string]string
+}
+
+func newDiagram(timeline []TimelineEntry) *diagram {
+	d := &diagram{
+		ids:      map[diagramPort]string{},
+		channels: map[diagramEndpoint]diagramEndpoint{},
+		acks:     map[string]string{},
+	}
+	seen := map[string]bool{}
+	var inits []diagramEndpoint
+	for _, e := range timeline {
+		if !seen[e.Chain] {
+			seen[e.Chain] = true
+			d.chains = append(d.chains, e.Chain)
+		}
+		if e.Kind != KindIBC {
+			continue
+		}
+		a := e.Attributes
+		switch e.Type {
+		case "channel_open_init":
+			inits = append(inits, diagramEndpoint{diagramPort{e.Chain, a["port_id"]}, a["channel_id"]})
+		case "channel_open_try":
+			// the try names the channel on the init side, but
+			// not its chain, so match it with an init on a
+			// different chain.
+			try := diagramEndpoint{diagramPort{e.Chain, a["port_id"]}, a["channel_id"]}
+			for i, init := range inits {
+				if init.chain != try.chain && init.port == a["counterparty_port_id"] && init.channel == a["counterparty_channel_id"] {
+					d.channels[init] = try
+					d.channels[try] = init
+					inits = append(inits[:i], inits[i+1:]...)
+					break
+				}
+			}
+		case "write_acknowledgement":
+			d.acks[packetKey(e.Chain, a)] = a["packet_ack"]
+		}
+	}
+	sort.Strings(d.chains)
+	return d
+}
+
+// The other end of a channel. Channels opened before recording
+// started are assumed to go to the only other chain, if there is
+// one.
+func (d *diagram) counterparty(e diagramEndpoint, port string) diagramEndpoint {
+	if other, ok := d.channels[e]; ok {
+		return other
+	}
+	chain := "unknown"
+	if len(d.chains) == 2 {
+		chain = d.chains[0]
+		if chain == e.chain {
+			chain = d.chains[1]
+		}
+	}
+	return diagramEndpoint{diagramPort{chain, port}, ""}
+}
+
+// The Mermaid ID of a participant, allocating one if needed.
+func (d *diagram) id(p diagramPort) string {
+	if id, ok := d.ids[p]; ok {
+		return id
+	}
+	id := fmt.Sprintf("p%d", len(d.ids))
+	d.ids[p] = id
+	return id
+}
+
+// Participants ordered by chain then port so the chains' columns
+// stay in the same order between runs.
+func (d *diagram) participants() []diagramPort {
+	ps := make([]diagramPort, 0, len(d.ids))
+	for p := range d.ids {
+		ps = append(ps, p)
+	}
+	sort.Slice(ps, func(i, j int) bool {
+		if ps[i].chain != ps[j].chain {
+			return ps[i].chain < ps[j].chain
+		}
+		return ps[i].port < ps[j].port
+	})
+	return ps
+}
+
+// Identifies a packet by the chain that received it, its
+// destination, and its sequence.
+func packetKey(chain string, a map[string]string) string {
+	return strings.Join([]string{chain, a["packet_dst_port"], a["packet_dst_channel"], a["packet_sequence"]}, "/")
+}
+
+func handshakeStep(eventType string) string {
+	switch eventType {
+	case "channel_open_init":
+		return "OpenInit"
+	case "channel_open_try":
+		return "OpenTry"
+	case "channel_open_ack":
+		return "OpenAck"
+	case "channel_open_confirm":
+		return "OpenConfirm"
+	case "channel_close_init":
+		return "CloseInit"
+	default:
+		return "CloseConfirm"
+	}
+}
+
+// Summarizes an acknowledgement as `result <data>` or `error <msg>`.
+// Acks which aren't in the standard format are returned as is.
+func ackResult(ack string) string {
+	var parsed struct {
+		Result *string `json:"result"`
+		Error  *string `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(ack), &parsed); err == nil {
+		switch {
+		case parsed.Result != nil:
+			return "result " + *parsed.Result
+		case parsed.Error != nil:
+			return "error " + *parsed.Error
+		}
+	}
+	return ack
+}
+
+// Escapes characters which end or otherwise break a Mermaid message.
+func mermaidText(s string) string {
+	return strings.NewReplacer("#", "#35;", ";", "#59;", "\n", " ").Replace(s)
+}
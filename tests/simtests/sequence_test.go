@@ -0,0 +1,65 @@
+package simtests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequenceDiagram(t *testing.T) {
+	ibc := func(chain, eventType string, attrs ...string) TimelineEntry {
+		e := TimelineEntry{Chain: chain, Kind: KindIBC, Type: eventType, Attributes: map[string]string{}}
+		for i := 0; i < len(attrs); i += 2 {
+			e.Attributes[attrs[i]] = attrs[i+1]
+		}
+		return e
+	}
+	channel := func(chain, eventType, port, channel, cpPort, cpChannel string) TimelineEntry {
+		return ibc(chain, eventType, "port_id", port, "channel_id", channel, "counterparty_port_id", cpPort, "counterparty_channel_id", cpChannel)
+	}
+	packet := func(chain, eventType, seq string, extra ...string) TimelineEntry {
+		return ibc(chain, eventType, append([]string{
+			"packet_sequence", seq,
+			"packet_src_port", "wasm.a", "packet_src_channel", "channel-3",
+			"packet_dst_port", "wasm.b", "packet_dst_channel", "channel-7",
+		}, extra...)...)
+	}
+
+	timeline := []TimelineEntry{
+		{Chain: "chain-a", Kind: KindBlock},
+		channel("chain-a", "channel_open_init", "wasm.a", "channel-3", "wasm.b", ""),
+		channel("chain-b", "channel_open_try", "wasm.b", "channel-7", "wasm.a", "channel-3"),
+		channel("chain-a", "channel_open_ack", "wasm.a", "channel-3", "wasm.b", "channel-7"),
+		channel("chain-b", "channel_open_confirm", "wasm.b", "channel-7", "wasm.a", "channel-3"),
+		packet("chain-a", "send_packet", "1"),
+		packet("chain-b", "recv_packet", "1"),
+		packet("chain-b", "write_acknowledgement", "1", "packet_ack", `{"error":"no; way"}`),
+		packet("chain-a", "acknowledge_packet", "1"),
+		packet("chain-a", "send_packet", "2"),
+		packet("chain-a", "timeout_packet", "2"),
+		channel("chain-a", "channel_close_init", "wasm.a", "channel-3", "wasm.b", "channel-7"),
+		channel("chain-b", "channel_close_confirm", "wasm.b", "channel-7", "wasm.a", "channel-3"),
+	}
+
+	require.Equal(t, `sequenceDiagram
+    participant p0 as chain-a<br/>wasm.a
+    participant p1 as chain-b<br/>wasm.b
+    p0->>p1: OpenInit channel-3
+    p1->>p0: OpenTry channel-7
+    p0->>p1: OpenAck channel-3
+    p1->>p0: OpenConfirm channel-7
+    Note over p0: send seq 1 on channel-3
+    p0->>p1: recv seq 1 channel-3→channel-7
+    p1-->>p0: ack seq 1 channel-7→channel-3: error no#59; way
+    Note over p0: send seq 2 on channel-3
+    p1--xp0: timeout seq 2 channel-7→channel-3
+    p0->>p1: CloseInit channel-3
+    p1->>p0: CloseConfirm channel-7
+`, SequenceDiagram(timeline))
+}
+
+func TestAckResult(t *testing.T) {
+	require.Equal(t, "result MQ==", ackResult(`{"result":"MQ=="}`))
+	require.Equal(t, "error bad", ackResult(`{"error":"bad"}`))
+	require.Equal(t, "custom", ackResult("custom"))
+}
@@ -0,0 +1,164 @@
+package simtests
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata/ instead of comparing against them")
+
+// cw2 contract version information.
+type ContractVersion struct {
+	Contract string `json:"contract"`
+	Version  string `json:"version"`
+}
+
+// The decoded state of a cw_ibc_example contract. See
+// `../../src/state.rs`.
+type ContractState struct {
+	Version       ContractVersion   `json:"version"`
+	Counts        map[string]uint32 `json:"counts"`
+	TimeoutCounts map[string]uint32 `json:"timeout_counts"`
+	// Anything we don't know how to decode, as hex key -> raw
+	// value, so that new state shows up in snapshots instead of
+	// being silently dropped.
+	Other map[string]string `json:"other,omitempty"`
+}
+
+// Reads and decodes all of a contract's storage.
+func ReadContractState(t *testing.T, chain *ibctesting.TestChain, contract sdk.AccAddress) ContractState {
+	state, err := readContractState(chain, contract)
+	require.NoError(t, err)
+	return state
+}
+
+func readContractState(chain *ibctesting.TestChain, contract sdk.AccAddress) (ContractState, error) {
+	state := ContractState{
+		Counts:        map[string]uint32{},
+		TimeoutCounts: map[string]uint32{},
+	}
+	var err error
+	chain.App.WasmKeeper.IterateContractState(chain.GetContext(), contract, func(key, value []byte) bool {
+		namespace, rest := splitNamespace(key)
+		switch namespace {
+		case "contract_info":
+			err = json.Unmarshal(value, &state.Version)
+		case "connection_counts":
+			state.Counts[string(rest)], err = parseCount(value)
+		case "timeout_count":
+			state.TimeoutCounts[string(rest)], err = parseCount(value)
+		default:
+			if state.Other == nil {
+				state.Other = map[string]string{}
+			}
+			state.Other[hex.EncodeToString(key)] = string(value)
+		}
+		return err != nil
+	})
+	return state, err
+}
+
+// Renames channels to `channel-N` where N is the channel's position
+// when the channels the contract knows about are sorted. Channel IDs
+// depend on how many channels were opened before the contract's, so
+// this keeps snapshots stable when unrelated setup changes.
+func (s ContractState) Normalize() ContractState {
+	var channels []string
+	seen := map[string]bool{}
+	for _, m := range []map[string]uint32{s.Counts, s.TimeoutCounts} {
+		for channel := range m {
+			if !seen[channel] {
+				seen[channel] = true
+				channels = append(channels, channel)
+			}
+		}
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		a, b := channelNumber(channels[i]), channelNumber(channels[j])
+		if a == b {
+			return channels[i] < channels[j]
+		}
+		return a < b
+	})
+	rename := map[string]string{}
+	for i, channel := range channels {
+		rename[channel] = "channel-" + strconv.Itoa(i)
+	}
+
+	normalized := s
+	normalized.Counts = map[string]uint32{}
+	for channel, count := range s.Counts {
+		normalized.Counts[rename[channel]] = count
+	}
+	normalized.TimeoutCounts = map[string]uint32{}
+	for channel, count := range s.TimeoutCounts {
+		normalized.TimeoutCounts[rename[channel]] = count
+	}
+	return normalized
+}
+
+// The normalized state of both of the fixture's contracts, keyed by
+// chain ID.
+func (f *Fixture) Snapshot(t *testing.T) map[string]ContractState {
+	return map[string]ContractState{
+		f.ChainA.ChainID: ReadContractState(t, f.ChainA, f.ContractA).Normalize(),
+		f.ChainB.ChainID: ReadContractState(t, f.ChainB, f.ContractB).Normalize(),
+	}
+}
+
+// Compares the JSON encoding of `got` to `testdata/<name>.golden`.
+// Run `go test ./... -update` to rewrite the golden file instead.
+func RequireGolden(t *testing.T, name string, got any) {
+	actual, err := json.MarshalIndent(got, "", "  ")
+	require.NoError(t, err)
+	actual = append(actual, '\n')
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, actual, 0o644))
+		return
+	}
+	expected, err := os.ReadFile(path)
+	require.NoError(t, err, "run `go test -update` to create the golden file")
+	require.Equal(t, string(expected), string(actual), "golden file (%s) is out of date, run `go test -update` if this is expected", path)
+}
+
+// Splits a cw-storage-plus key into its namespace and the rest of the
+// key. Keys which aren't length-prefixed are `Item`s and are returned
+// whole as the namespace.
+func splitNamespace(key []byte) (string, []byte) {
+	if len(key) >= 2 {
+		n := int(binary.BigEndian.Uint16(key))
+		if len(key) >= 2+n {
+			return string(key[2 : 2+n]), key[2+n:]
+		}
+	}
+	return string(key), nil
+}
+
+func parseCount(value []byte) (uint32, error) {
+	var count uint32
+	err := json.Unmarshal(value, &count)
+	return count, err
+}
+
+func channelNumber(channel string) int {
+	n, err := strconv.Atoi(strings.TrimPrefix(channel, "channel-"))
+	if err != nil {
+		return -1
+	}
+	return n
+}
@@ -0,0 +1,31 @@
+package simtests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Counts in both directions and times out a packet, then compares
+// the state of both contracts with `testdata/counting.golden`.
+func TestCountingSnapshot(t *testing.T) {
+	f := SetupFixture(t)
+
+	_, err := f.A.ExecuteIncrement(t, &f.ContractA, f.Path.EndpointA.ChannelID)
+	require.NoError(t, err)
+	_, err = f.A.ExecuteIncrement(t, &f.ContractA, f.Path.EndpointA.ChannelID)
+	require.NoError(t, err)
+	_, err = f.B.ExecuteIncrement(t, &f.ContractB, f.Path.EndpointB.ChannelID)
+	require.NoError(t, err)
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+
+	// send a packet and let it sit around until after its two
+	// minute timeout.
+	_, err = f.A.ExecuteIncrement(t, &f.ContractA, f.Path.EndpointA.ChannelID)
+	require.NoError(t, err)
+	f.Coordinator.IncrementTimeBy(DefaultTimeout + time.Minute)
+	require.NoError(t, TimeoutPendingPackets(f.Path))
+
+	RequireGolden(t, "counting", f.Snapshot(t))
+}
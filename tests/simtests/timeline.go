@@ -0,0 +1,272 @@
+package simtests
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	wasmtypes "github.com/CosmWasm/wasmd/x/wasm/types"
+	"github.com/cosmos/cosmos-sdk/store/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+// Kinds of timeline entries.
+const (
+	KindBlock = "block"
+	KindTx    = "tx"
+	KindWasm  = "wasm"
+	KindIBC   = "ibc"
+)
+
+// Something that happened on a chain.
+type TimelineEntry struct {
+	Chain  string    `json:"chain"`
+	Height int64     `json:"height"`
+	Time   time.Time `json:"time"`
+	Kind   string    `json:"kind"`
+	// For transactions, the type URLs of the messages and the error
+	// if the transaction failed.
+	Msgs  []string `json:"msgs,omitempty"`
+	Error string   `json:"error,omitempty"`
+	// For events, the event type and its attributes.
+	Type       string            `json:"type,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// Collects the transactions, blocks, and wasm and IBC events on every
+// chain in a coordinator into a timeline.
+//
+// Blocks are seen directly. Transactions and their events are only
+// seen if they go through the helpers in this package (`Account.Send`,
+// the helpers in `relay.go` and `Peer.SendPacket`) as the test app
+// delivers transactions without telling any listeners.
+type Recorder struct {
+	mu      sync.Mutex
+	entries []TimelineEntry
+}
+
+var recorders = struct {
+	sync.Mutex
+	m map[*ibctesting.Coordinator]*Recorder
+}{m: map[*ibctesting.Coordinator]*Recorder{}}
+
+// Starts recording everything that happens on the coordinator's
+// chains. When the test finishes the timeline is written to
+// `<name of test>.timeline.json`, and a sequence diagram of it (see
+// `SequenceDiagram`) to `<name of test>.mmd`, in the test's output
+// directory (set with `go test -outputdir`), or in `timelines/` if
+// that isn't set.
+func NewRecorder(t testing.TB, coord *ibctesting.Coordinator) *Recorder {
+	r := &Recorder{}
+	for _, chain := range coord.Chains {
+		chain.App.SetStreamingService(&blockListener{chain: chain.ChainID, recorder: r})
+	}
+
+	recorders.Lock()
+	recorders.m[coord] = r
+	recorders.Unlock()
+
+	t.Cleanup(func() {
+		recorders.Lock()
+		delete(recorders.m, coord)
+		recorders.Unlock()
+
+		path := filepath.Join(timelineDir(), timelineFileName(t.Name(), ".timeline.json"))
+		if err := r.WriteFile(path); err != nil {
+			t.Logf("writing timeline: %s", err)
+			return
+		}
+		t.Logf("timeline written to %s", path)
+		path = filepath.Join(timelineDir(), timelineFileName(t.Name(), ".mmd"))
+		if err := r.WriteSequenceDiagram(path); err != nil {
+			t.Logf("writing sequence diagram: %s", err)
+		}
+	})
+	return r
+}
+
+// All of the recorded entries ordered by time, then by chain and
+// height. Entries at the same time and height on a chain are in the
+// order they happened.
+func (r *Recorder) Timeline() []TimelineEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order := make([]int, len(r.entries))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := r.entries[order[i]], r.entries[order[j]]
+		switch {
+		case !a.Time.Equal(b.Time):
+			return a.Time.Before(b.Time)
+		case a.Chain != b.Chain:
+			return a.Chain < b.Chain
+		case a.Height != b.Height:
+			return a.Height < b.Height
+		}
+		return order[i] < order[j]
+	})
+	timeline := make([]TimelineEntry, len(order))
+	for i, index := range order {
+		timeline[i] = r.entries[index]
+	}
+	return timeline
+}
+
+// Writes the timeline as JSON.
+func (r *Recorder) WriteFile(path string) error {
+	bz, err := json.MarshalIndent(r.Timeline(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(bz, '\n'), 0o644)
+}
+
+func (r *Recorder) add(entries ...TimelineEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entries...)
+}
+
+// Records a transaction delivered at `height` and time `at` on
+// `chain` along with its wasm and IBC events.
+func recordTx(chain *ibctesting.TestChain, height int64, at time.Time, msgs []sdk.Msg, res *sdk.Result, err error) {
+	r := recorderOf(chain)
+	if r == nil {
+		return
+	}
+
+	tx := TimelineEntry{
+		Chain:  chain.ChainID,
+		Height: height,
+		Time:   at,
+		Kind:   KindTx,
+	}
+	for _, msg := range msgs {
+		tx.Msgs = append(tx.Msgs, sdk.MsgTypeURL(msg))
+	}
+	if err != nil {
+		tx.Error = err.Error()
+	}
+	entries := []TimelineEntry{tx}
+	if res != nil {
+		base := tx
+		base.Msgs = nil
+		entries = append(entries, eventEntries(base, res.Events)...)
+	}
+	r.add(entries...)
+}
+
+// Records the wasm and IBC events of something done on `chain` at
+// `height` and time `at` outside of a transaction, like a `Peer`
+// sending a packet.
+func recordEvents(chain *ibctesting.TestChain, height int64, at time.Time, events sdk.Events) {
+	r := recorderOf(chain)
+	if r == nil {
+		return
+	}
+	r.add(eventEntries(TimelineEntry{Chain: chain.ChainID, Height: height, Time: at}, events.ToABCIEvents())...)
+}
+
+func recorderOf(chain *ibctesting.TestChain) *Recorder {
+	recorders.Lock()
+	defer recorders.Unlock()
+	return recorders.m[chain.Coordinator]
+}
+
+// Entries like `base` for each of the interesting events.
+func eventEntries(base TimelineEntry, events []abci.Event) []TimelineEntry {
+	var entries []TimelineEntry
+	for _, event := range events {
+		kind := eventKind(event.Type)
+		if kind == "" {
+			continue
+		}
+		entry := base
+		entry.Kind = kind
+		entry.Type = event.Type
+		entry.Attributes = map[string]string{}
+		for _, attr := range event.Attributes {
+			entry.Attributes[string(attr.Key)] = string(attr.Value)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// The kind of timeline entry for an event type, or "" if the event
+// isn't interesting.
+func eventKind(eventType string) string {
+	switch {
+	case eventType == wasmtypes.WasmModuleEventType:
+		return KindWasm
+	case strings.HasPrefix(eventType, "channel_"),
+		strings.HasPrefix(eventType, "connection_"),
+		strings.HasSuffix(eventType, "_client"),
+		strings.HasSuffix(eventType, "_packet"),
+		eventType == "write_acknowledgement":
+		return KindIBC
+	}
+	return ""
+}
+
+func timelineDir() string {
+	if f := flag.Lookup("test.outputdir"); f != nil && f.Value.String() != "" {
+		return f.Value.String()
+	}
+	return "timelines"
+}
+
+func timelineFileName(testName, ext string) string {
+	return strings.NewReplacer("/", "_", " ", "_").Replace(testName) + ext
+}
+
+// Records blocks as they are ended. Implements
+// `baseapp.StreamingService`.
+type blockListener struct {
+	chain    string
+	recorder *Recorder
+	// the time from the latest BeginBlock. ibctesting calls
+	// BeginBlock many times per block to update the block time.
+	time time.Time
+}
+
+func (l *blockListener) ListenBeginBlock(_ context.Context, req abci.RequestBeginBlock, _ abci.ResponseBeginBlock) error {
+	l.time = req.Header.Time
+	return nil
+}
+
+func (l *blockListener) ListenEndBlock(_ context.Context, req abci.RequestEndBlock, _ abci.ResponseEndBlock) error {
+	l.recorder.add(TimelineEntry{
+		Chain:  l.chain,
+		Height: req.Height,
+		Time:   l.time,
+		Kind:   KindBlock,
+	})
+	return nil
+}
+
+func (l *blockListener) ListenDeliverTx(context.Context, abci.RequestDeliverTx, abci.ResponseDeliverTx) error {
+	return nil
+}
+
+func (l *blockListener) ListenCommit(context.Context, abci.ResponseCommit) error {
+	return nil
+}
+
+func (l *blockListener) Stream(*sync.WaitGroup) error                        { return nil }
+func (l *blockListener) Listeners() map[types.StoreKey][]types.WriteListener { return nil }
+func (l *blockListener) Close() error                                        { return nil }
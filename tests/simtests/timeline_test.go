@@ -0,0 +1,63 @@
+package simtests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Sends a packet from A to B and checks that the recorder saw the
+// execute on A, the receive on B (where the contract also reports
+// `execute_increment`), and the ack back on A, in that order.
+func TestTimelineRecordsPacketLifecycle(t *testing.T) {
+	f := SetupFixture(t)
+
+	_, err := f.A.ExecuteIncrement(t, &f.ContractA, f.Path.EndpointA.ChannelID)
+	require.NoError(t, err)
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+
+	var seen []string
+	for _, entry := range f.Recorder.Timeline() {
+		switch {
+		case entry.Kind == KindWasm && entry.Attributes["method"] == "execute_increment":
+			seen = append(seen, entry.Chain+" execute")
+		case entry.Kind == KindIBC && entry.Type == "recv_packet":
+			seen = append(seen, entry.Chain+" recv")
+		case entry.Kind == KindIBC && entry.Type == "acknowledge_packet":
+			seen = append(seen, entry.Chain+" ack")
+		}
+	}
+	require.Equal(t, []string{
+		f.ChainA.ChainID + " execute",
+		f.ChainB.ChainID + " recv",
+		f.ChainB.ChainID + " execute",
+		f.ChainA.ChainID + " ack",
+	}, seen)
+}
+
+// Entries at the same time come out ordered by chain and height
+// whichever order they were recorded in.
+func TestTimelineOrdersTies(t *testing.T) {
+	at := time.Date(2020, 12, 4, 10, 30, 0, 0, time.UTC)
+	r := &Recorder{}
+	r.add(
+		TimelineEntry{Chain: "testchain1", Height: 4, Time: at, Kind: KindTx},
+		TimelineEntry{Chain: "testchain0", Height: 7, Time: at, Kind: KindBlock},
+		TimelineEntry{Chain: "testchain1", Height: 3, Time: at, Kind: KindBlock},
+		TimelineEntry{Chain: "testchain0", Height: 7, Time: at.Add(-time.Second), Kind: KindBlock},
+		TimelineEntry{Chain: "testchain1", Height: 4, Time: at, Kind: KindBlock},
+	)
+	var order []string
+	for _, entry := range r.Timeline() {
+		order = append(order, fmt.Sprintf("%s@%d %s %s", entry.Chain, entry.Height, entry.Time.Format("05"), entry.Kind))
+	}
+	require.Equal(t, []string{
+		"testchain0@7 59 block",
+		"testchain0@7 00 block",
+		"testchain1@3 00 block",
+		"testchain1@4 00 tx",
+		"testchain1@4 00 block",
+	}, order)
+}
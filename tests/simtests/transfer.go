@@ -0,0 +1,97 @@
+package simtests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	transfertypes "github.com/cosmos/ibc-go/v4/modules/apps/transfer/types"
+	clienttypes "github.com/cosmos/ibc-go/v4/modules/core/02-client/types"
+	channeltypes "github.com/cosmos/ibc-go/v4/modules/core/04-channel/types"
+	sdkibctesting "github.com/cosmos/ibc-go/v4/testing"
+)
+
+// Helpers for ICS-20 transfer channels, see `WithTransferChannel`.
+
+// Also opens an ICS-20 transfer channel on the same connection as the
+// counter-1 channel. It's opened after the counter-1 channel, and is
+// the fixture's `TransferPath`.
+func WithTransferChannel() FixtureOption {
+	return func(o *fixtureOptions) {
+		o.transfer = true
+	}
+}
+
+// The config for a transfer channel.
+func TransferChannelConfig(opts ...FixtureOption) *sdkibctesting.ChannelConfig {
+	config := &sdkibctesting.ChannelConfig{
+		PortID:  transfertypes.PortID,
+		Version: transfertypes.Version,
+		Order:   channeltypes.UNORDERED,
+	}
+	if newFixtureOptions(opts).fees {
+		config.Version = FeeVersion(config.Version)
+	}
+	return config
+}
+
+// Opens a channel on `connected`'s connection, so the new path
+// shares its clients and connection.
+func openChannelOn(connected *ibctesting.Path, a, b *sdkibctesting.ChannelConfig) (*ibctesting.Path, error) {
+	path := pathOn(connected, a, b)
+	if err := OpenChannel(path); err != nil {
+		return nil, err
+	}
+	return path, nil
+}
+
+// A path for a new channel on `connected`'s connection.
+func pathOn(connected *ibctesting.Path, a, b *sdkibctesting.ChannelConfig) *ibctesting.Path {
+	path := ibctesting.NewPath(connected.EndpointA.Chain, connected.EndpointB.Chain)
+	path.EndpointA.ChannelConfig, path.EndpointB.ChannelConfig = a, b
+	path.EndpointA.ClientID, path.EndpointA.ConnectionID = connected.EndpointA.ClientID, connected.EndpointA.ConnectionID
+	path.EndpointB.ClientID, path.EndpointB.ConnectionID = connected.EndpointB.ClientID, connected.EndpointB.ConnectionID
+	return path
+}
+
+// Sends `coin` from the account to `receiver` on the other end of the
+// endpoint's transfer channel. The transfer times out after
+// `DefaultTimeout`, like the contract's packets.
+func (a *Account) Transfer(t *testing.T, endpoint *ibctesting.Endpoint, receiver sdk.AccAddress, coin sdk.Coin) error {
+	timeout := a.Chain.CurrentHeader.Time.Add(DefaultTimeout)
+	_, err := a.Send(t, transfertypes.NewMsgTransfer(
+		endpoint.ChannelConfig.PortID, endpoint.ChannelID,
+		coin, a.Address.String(), receiver.String(),
+		clienttypes.ZeroHeight(), uint64(timeout.UnixNano()),
+	))
+	return err
+}
+
+// The denom of the vouchers the endpoint's chain mints for `denom`
+// when it's transferred in over the endpoint's channel. `denom` is the
+// denom on the sending chain, which may itself be a voucher's trace
+// path, for example "transfer/channel-1/stake".
+func VoucherDenom(endpoint *ibctesting.Endpoint, denom string) string {
+	prefixed := transfertypes.GetPrefixedDenom(endpoint.ChannelConfig.PortID, endpoint.ChannelID, denom)
+	return transfertypes.ParseDenomTrace(prefixed).IBCDenom()
+}
+
+// Requires that the chain knows the trace of an `ibc/...` denom, and
+// that it's `path` (for example "transfer/channel-1") and
+// `baseDenom`.
+func (f *Fixture) RequireDenomTrace(t *testing.T, chain *ibctesting.TestChain, ibcDenom, path, baseDenom string) {
+	t.Helper()
+	hash, err := transfertypes.ParseHexHash(strings.TrimPrefix(ibcDenom, transfertypes.DenomPrefix+"/"))
+	if err != nil || !strings.HasPrefix(ibcDenom, transfertypes.DenomPrefix+"/") {
+		f.require(t, fmt.Sprintf("%s is not an IBC denom", ibcDenom))
+	}
+	trace, ok := chain.App.TransferKeeper.GetDenomTrace(chain.GetContext(), hash)
+	switch {
+	case !ok:
+		f.require(t, fmt.Sprintf("%s has no denom trace for %s", chain.ChainID, ibcDenom))
+	case trace.Path != path || trace.BaseDenom != baseDenom:
+		f.require(t, fmt.Sprintf("%s on %s: expected trace %s/%s, got %s", ibcDenom, chain.ChainID, path, baseDenom, trace.GetFullDenomPath()))
+	}
+}
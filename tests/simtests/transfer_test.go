@@ -0,0 +1,71 @@
+package simtests
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferChannelSharesConnection(t *testing.T) {
+	f := SetupFixture(t, WithTransferChannel())
+	counter, transfer := f.Path, f.TransferPath
+
+	require.Equal(t, counter.EndpointA.ConnectionID, transfer.EndpointA.ConnectionID)
+	require.Equal(t, counter.EndpointB.ConnectionID, transfer.EndpointB.ConnectionID)
+	// channel IDs are allocated per chain, whatever the port.
+	require.Equal(t, "channel-0", counter.EndpointA.ChannelID)
+	require.Equal(t, "channel-1", transfer.EndpointA.ChannelID)
+	f.RequireChannelOpen(t, transfer.EndpointA)
+}
+
+func TestTransfersAndContractPackets(t *testing.T) {
+	f := SetupFixture(t, WithTransferChannel())
+	transfer := f.TransferPath
+
+	_, err := f.A.ExecuteIncrement(t, &f.ContractA, f.Path.EndpointA.ChannelID)
+	require.NoError(t, err)
+	require.NoError(t, f.A.Transfer(t, transfer.EndpointA, f.B.Address, sdk.NewInt64Coin(sdk.DefaultBondDenom, 100)))
+	require.Len(t, f.ChainA.PendingSendPackets, 2)
+
+	// relaying one channel leaves the other's packets alone.
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+	f.RequireCount(t, f.CounterB(), f.Path.EndpointB.ChannelID, 1)
+	fromA, _, err := PendingPackets(transfer)
+	require.NoError(t, err)
+	require.Len(t, fromA, 1)
+
+	require.NoError(t, RelayAndAckPendingPackets(transfer))
+	f.RequireNoPendingPackets(t, f.Path, transfer)
+	voucher := VoucherDenom(transfer.EndpointB, sdk.DefaultBondDenom)
+	f.RequireDenomTrace(t, f.ChainB, voucher, "transfer/channel-1", sdk.DefaultBondDenom)
+	f.RequireBalance(t, f.ChainA, f.A.Address, stake(100_000_000-100))
+	f.RequireBalance(t, f.ChainB, f.B.Address, stake(100_000_000).Add(sdk.NewInt64Coin(voucher, 100)))
+
+	// sending vouchers back unwinds them.
+	require.NoError(t, f.B.Transfer(t, transfer.EndpointB, f.A.Address, sdk.NewInt64Coin(voucher, 40)))
+	require.NoError(t, RelayAndAckPendingPackets(transfer))
+	f.RequireBalance(t, f.ChainA, f.A.Address, stake(100_000_000-60))
+	f.RequireBalance(t, f.ChainB, f.B.Address, stake(100_000_000).Add(sdk.NewInt64Coin(voucher, 60)))
+	f.RequireConverged(t, f.Path, transfer)
+}
+
+func TestTransferPathConverges(t *testing.T) {
+	f := SetupFixture(t, WithTransferChannel())
+	transfer := f.TransferPath
+	f.RequireConverged(t, transfer)
+
+	require.NoError(t, f.A.Transfer(t, transfer.EndpointA, f.B.Address, sdk.NewInt64Coin(sdk.DefaultBondDenom, 100)))
+	require.Equal(t, []string{
+		"channel-1 on testchain0: 1 packets have not been acknowledged or timed out",
+	}, checkConverged(transfer.EndpointA))
+	require.NoError(t, RelayAndAckPendingPackets(transfer))
+	f.RequireConverged(t, transfer)
+}
+
+func TestTransferChannelWithFees(t *testing.T) {
+	f := SetupFixture(t, WithFees(), WithTransferChannel())
+	endpoint := f.TransferPath.EndpointA
+	require.Equal(t, FeeVersion("ics20-1"), endpoint.GetChannel().Version)
+	require.True(t, f.ChainA.App.IBCFeeKeeper.IsFeeEnabled(f.ChainA.GetContext(), endpoint.ChannelConfig.PortID, endpoint.ChannelID))
+}
@@ -0,0 +1,57 @@
+package simtests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CosmWasm/wasmd/x/wasm/ibctesting"
+	"github.com/cosmos/cosmos-sdk/x/upgrade"
+	upgradetypes "github.com/cosmos/cosmos-sdk/x/upgrade/types"
+	"github.com/stretchr/testify/require"
+)
+
+// Schedules a software upgrade called `name` at `height` on `chain`,
+// as a passed upgrade proposal would.
+func ScheduleUpgrade(t *testing.T, chain *ibctesting.TestChain, name string, height int64) {
+	plan := upgradetypes.Plan{Name: name, Height: height, Info: "scheduled by simtests"}
+	recordUnreplayable(chain, "ScheduleUpgrade", func() {
+		require.NoError(t, chain.App.UpgradeKeeper.ScheduleUpgrade(chain.GetContext(), plan))
+	})
+}
+
+// Runs `chain` up to the height of its scheduled upgrade, where it
+// halts as its software has no handler for the upgrade. Then installs
+// `handler`, as the upgraded software would, and resumes `downtime`
+// later, which runs the handler at the upgrade height.
+//
+// The coordinator's clock is shared, so every chain moves on by
+// `downtime`, and packets in flight to `chain` whose timeout falls in
+// it time out instead of being received.
+func UpgradeChain(t *testing.T, chain *ibctesting.TestChain, handler upgradetypes.UpgradeHandler, downtime time.Duration) upgradetypes.Plan {
+	var plan upgradetypes.Plan
+	recordUnreplayable(chain, "UpgradeChain", func() {
+		plan = upgradeChain(t, chain, handler, downtime)
+	})
+	return plan
+}
+
+func upgradeChain(t *testing.T, chain *ibctesting.TestChain, handler upgradetypes.UpgradeHandler, downtime time.Duration) upgradetypes.Plan {
+	plan, found := chain.App.UpgradeKeeper.GetUpgradePlan(chain.GetContext())
+	require.True(t, found, "no upgrade is scheduled on %s", chain.ChainID)
+	require.Less(t, chain.CurrentHeader.Height, plan.Height, "%s is past the upgrade height", chain.ChainID)
+
+	if n := plan.Height - chain.CurrentHeader.Height - 1; n > 0 {
+		chain.Coordinator.CommitNBlocks(chain, uint64(n))
+	}
+	// Commits the block before the upgrade and begins the upgrade
+	// block, which the old software refuses to do.
+	require.PanicsWithValue(t, upgrade.BuildUpgradeNeededMsg(plan), chain.NextBlock, "%s didn't halt for the upgrade", chain.ChainID)
+
+	chain.App.UpgradeKeeper.SetUpgradeHandler(plan.Name, handler)
+	// Begins the upgrade block again on every chain, this time with
+	// the handler installed.
+	chain.Coordinator.IncrementTimeBy(downtime)
+	require.Equal(t, plan.Height, chain.App.UpgradeKeeper.GetDoneHeight(chain.GetContext(), plan.Name), "%s didn't apply the upgrade", chain.ChainID)
+	chain.Coordinator.CommitBlock(chain)
+	return plan
+}
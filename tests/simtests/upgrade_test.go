@@ -0,0 +1,73 @@
+package simtests
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/module"
+	upgradetypes "github.com/cosmos/cosmos-sdk/x/upgrade/types"
+	"github.com/stretchr/testify/require"
+)
+
+// An upgrade handler that doesn't migrate anything and counts how
+// many times it ran.
+func noopUpgrade(ran *int) upgradetypes.UpgradeHandler {
+	return func(_ sdk.Context, _ upgradetypes.Plan, fromVM module.VersionMap) (module.VersionMap, error) {
+		*ran++
+		return fromVM, nil
+	}
+}
+
+func TestUpgradeWithPacketsInFlight(t *testing.T) {
+	f := SetupFixture(t)
+	channelA, channelB := f.Path.EndpointA.ChannelID, f.Path.EndpointB.ChannelID
+
+	for i := 0; i < 2; i++ {
+		_, err := f.A.ExecuteIncrement(t, &f.ContractA, channelA)
+		require.NoError(t, err)
+	}
+	_, err := f.B.ExecuteIncrement(t, &f.ContractB, channelB)
+	require.NoError(t, err)
+
+	var ran int
+	ScheduleUpgrade(t, f.ChainB, "v2", f.ChainB.CurrentHeader.Height+2)
+	plan := UpgradeChain(t, f.ChainB, noopUpgrade(&ran), 10*time.Second)
+	require.Equal(t, 1, ran)
+	require.Greater(t, f.ChainB.CurrentHeader.Height, plan.Height)
+
+	// packets sent both ways before the upgrade arrive after it.
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+	f.RequireCount(t, f.CounterB(), channelB, 2)
+	f.RequireCount(t, f.CounterA(), channelA, 1)
+
+	_, err = f.B.ExecuteIncrement(t, &f.ContractB, channelB)
+	require.NoError(t, err)
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+	f.RequireCount(t, f.CounterA(), channelA, 2)
+	f.RequireConverged(t)
+}
+
+func TestUpgradeDowntimeTimesOutPackets(t *testing.T) {
+	f := SetupFixture(t)
+	channelA, channelB := f.Path.EndpointA.ChannelID, f.Path.EndpointB.ChannelID
+
+	_, err := f.A.ExecuteIncrement(t, &f.ContractA, channelA)
+	require.NoError(t, err)
+
+	var ran int
+	ScheduleUpgrade(t, f.ChainB, "v2", f.ChainB.CurrentHeader.Height+1)
+	UpgradeChain(t, f.ChainB, noopUpgrade(&ran), DefaultTimeout+time.Minute)
+	require.Equal(t, 1, ran)
+
+	require.NoError(t, TimeoutPendingPackets(f.Path))
+	f.RequireTimeoutCount(t, f.CounterA(), channelA, 1)
+	f.RequireCount(t, f.CounterB(), channelB, 0)
+
+	// the channel is still usable once the upgraded chain is back.
+	_, err = f.A.ExecuteIncrement(t, &f.ContractA, channelA)
+	require.NoError(t, err)
+	require.NoError(t, RelayAndAckPendingPackets(f.Path))
+	f.RequireCount(t, f.CounterB(), channelB, 1)
+	f.RequireConverged(t)
+}
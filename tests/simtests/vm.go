@@ -0,0 +1,47 @@
+package simtests
+
+import (
+	"testing"
+
+	wasmkeeper "github.com/CosmWasm/wasmd/x/wasm/keeper"
+	wasmvm "github.com/CosmWasm/wasmvm"
+	"github.com/stretchr/testify/require"
+)
+
+// The settings of a chain's wasm VM that wasmd reads from its app
+// config rather than taking keeper options for.
+type VMConfig struct {
+	// Comma separated, like wasmd's `availableCapabilities`.
+	Capabilities string
+	// The size of the in-memory module cache in MiB. Juno and
+	// wasmd default to 100.
+	MemoryCacheSize uint32
+	// Whether to log what contracts print.
+	Debug bool
+}
+
+// The VM config of the simulated chains' app.
+func DefaultVMConfig() VMConfig {
+	return VMConfig{
+		Capabilities:    "iterator,staking,stargate,cosmwasm_1_1,cosmwasm_1_2",
+		MemoryCacheSize: 100,
+	}
+}
+
+// How much memory a contract instance may use in MiB, as in wasmd.
+const contractMemoryLimit = 32
+
+// A wasm keeper option that replaces a chain's VM with one using
+// `config`. Use it with `WithWasmOptions`.
+func WithVM(t testing.TB, config VMConfig) wasmkeeper.Option {
+	return wasmkeeper.WithWasmEngine(newVM(t, config))
+}
+
+// A VM in a temporary directory that is cleaned up when the test
+// finishes.
+func newVM(t testing.TB, config VMConfig) *wasmvm.VM {
+	vm, err := wasmvm.NewVM(t.TempDir(), config.Capabilities, contractMemoryLimit, config.Debug, config.MemoryCacheSize)
+	require.NoError(t, err)
+	t.Cleanup(vm.Cleanup)
+	return vm
+}
@@ -0,0 +1,78 @@
+package simtests
+
+import (
+	"os"
+	"testing"
+
+	wasmkeeper "github.com/CosmWasm/wasmd/x/wasm/keeper"
+	wasmtypes "github.com/CosmWasm/wasmd/x/wasm/types"
+	wasmvmtypes "github.com/CosmWasm/wasmvm/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+	"withoutdoing.com/harness"
+)
+
+// Counts the messages contracts dispatch.
+type countingMessenger struct {
+	wasmkeeper.Messenger
+	dispatched *int
+}
+
+func (m countingMessenger) DispatchMsg(ctx sdk.Context, contract sdk.AccAddress, port string, msg wasmvmtypes.CosmosMsg) ([]sdk.Event, [][]byte, error) {
+	*m.dispatched++
+	return m.Messenger.DispatchMsg(ctx, contract, port, msg)
+}
+
+func countMessages(dispatched *int) wasmkeeper.Option {
+	return wasmkeeper.WithMessageHandlerDecorator(func(old wasmkeeper.Messenger) wasmkeeper.Messenger {
+		return countingMessenger{Messenger: old, dispatched: dispatched}
+	})
+}
+
+func TestWasmOptionsArePerChain(t *testing.T) {
+	var dispatched int
+	f := SetupFixture(t, WithWasmOptions(harness.A, countMessages(&dispatched)))
+
+	_, err := f.A.ExecuteIncrement(t, &f.ContractA, f.Path.EndpointA.ChannelID)
+	require.NoError(t, err)
+	_, err = f.B.ExecuteIncrement(t, &f.ContractB, f.Path.EndpointB.ChannelID)
+	require.NoError(t, err)
+	// the increment's IBC send packet message, on A only.
+	require.Equal(t, 1, dispatched)
+
+	// restarting keeps the chain's options.
+	f.RestartFromGenesis(t, f.ChainA)
+	_, err = f.A.ExecuteIncrement(t, &f.ContractA, f.Path.EndpointA.ChannelID)
+	require.NoError(t, err)
+	require.Equal(t, 2, dispatched)
+}
+
+func TestGasRegisterOption(t *testing.T) {
+	config := wasmkeeper.DefaultGasRegisterConfig()
+	config.GasMultiplier /= 10
+	expensive := WithWasmOptions(harness.A, wasmkeeper.WithGasRegister(wasmkeeper.NewWasmGasRegister(config)))
+
+	defaults := MeasureGas(t, SetupFixture(t))
+	measured := MeasureGas(t, SetupFixture(t, expensive))
+	for entryPoint, gas := range defaults {
+		require.Greater(t, measured[entryPoint], gas, entryPoint)
+	}
+}
+
+func TestVMCapabilities(t *testing.T) {
+	config := DefaultVMConfig()
+	config.Capabilities = "iterator"
+	h := NewHarness(t, WithWasmOptions(harness.A, WithVM(t, config)))
+	f := h.Fixture
+	code, err := os.ReadFile(WasmFile)
+	require.NoError(t, err)
+
+	// the contract is built with `ibc3`, which needs stargate.
+	account := GenAccount(t, f.ChainA)
+	_, err = account.Send(t, &wasmtypes.MsgStoreCode{Sender: account.Address.String(), WASMByteCode: code})
+	require.ErrorContains(t, err, "stargate")
+
+	account = GenAccount(t, f.ChainB)
+	_, err = account.Send(t, &wasmtypes.MsgStoreCode{Sender: account.Address.String(), WASMByteCode: code})
+	require.NoError(t, err)
+}
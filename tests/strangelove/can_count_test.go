@@ -2,9 +2,6 @@ package strangelove
 
 import (
 	"context"
-	"encoding/base64"
-	"encoding/hex"
-	"encoding/json"
 	"testing"
 
 	"github.com/strangelove-ventures/interchaintest/v4"
@@ -12,9 +9,11 @@ import (
 	"github.com/strangelove-ventures/interchaintest/v4/chain/cosmos/wasm"
 	"github.com/strangelove-ventures/interchaintest/v4/ibc"
 	"github.com/strangelove-ventures/interchaintest/v4/testreporter"
-	"github.com/strangelove-ventures/interchaintest/v4/testutil"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"withoutdoing.com/m/v2/contractstate"
 	"withoutdoing.com/m/v2/helper"
 )
 
@@ -142,49 +141,38 @@ func TestCanCount(t *testing.T) {
 	}
 
 	// Wait for the channel to get set up
-	err = testutil.WaitForBlocks(ctx, 10, left, right)
+	var channel helper.ChannelPair
+	err = helper.WaitUntil(ctx, left, helper.ChannelFound(relayer, erp, left.Config().ChainID, leftPort, rightPort, &channel), 10)
 	require.NoError(t, err)
-
-	channelInfo, err := relayer.GetChannels(ctx, erp, left.Config().ChainID)
-	if err != nil {
-		t.Fatal(err)
-	}
-	leftChannel := channelInfo[len(channelInfo)-1].ChannelID
-	channelInfo, err = relayer.GetChannels(ctx, erp, right.Config().ChainID)
-	if err != nil {
-		t.Fatal(err)
-	}
-	rightChannel := channelInfo[len(channelInfo)-2].ChannelID
+	leftChannel, rightChannel := channel.Local, channel.Remote
 
 	_, err = leftCosmosChain.ExecuteContract(ctx, leftUser.KeyName, leftContract, "{\"increment\": { \"channel\":\""+leftChannel+"\"}}")
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	// wait a couple blocks for the incrementing to relay to the
-	// right chain.
-	err = testutil.WaitForBlocks(ctx, 10, left, right)
+	// wait for the incrementing to relay to the right chain.
+	err = helper.WaitUntil(ctx, right, helper.CountReaches(rightCosmosChain, rightContract, rightChannel, 1), 10)
 	require.NoError(t, err)
 
-	cmd := []string{right.Config().Bin, "query", "wasm", "contract-state", "all", rightContract,
-		"--node", right.GetRPCAddress(),
-		"--home", right.HomeDir(),
-		"--chain-id", right.Config().ChainID,
-		"--output", "json",
-	}
-	stdout, _, err := right.Exec(ctx, cmd, nil)
+	// dump the right contract's state, a couple of entries at a
+	// time to show off pagination.
+	grpcConn, err := grpc.Dial(
+		rightCosmosChain.GetHostGRPCAddress(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
 	require.NoError(t, err)
-	results := &helper.ContractStateResp{}
-	err = json.Unmarshal(stdout, results)
-	require.NoError(t, err)
-
+	t.Cleanup(func() {
+		if err := grpcConn.Close(); err != nil {
+			t.Logf("closing GRPC: %s", err)
+		}
+	})
 	t.Log("dumping state")
-	for _, kv := range results.Models {
-		keyBytes, _ := hex.DecodeString(kv.Key)
-		valueBytes, err := base64.StdEncoding.DecodeString(kv.Value)
-		require.NoError(t, err)
-		t.Logf("------------> %s -> %s", string(keyBytes), string(valueBytes))
-	}
+	err = contractstate.Stream(ctx, contractstate.GRPCPages(grpcConn, rightContract, 2), nil, func(e contractstate.Entry) error {
+		t.Logf("------------> %s %v -> %s", e.Namespace, e.Key, e.Value)
+		return nil
+	})
+	require.NoError(t, err)
 
 	queryMsg := helper.QueryMsg{
 		GetCount: &helper.GetCount{Channel: rightChannel},
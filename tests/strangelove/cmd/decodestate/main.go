@@ -0,0 +1,96 @@
+// Decodes a contract state export.
+//
+//	junod query wasm contract-state all $CONTRACT --output json > state.json
+//	go run ./cmd/decodestate state.json
+//
+// Reads from stdin if no file is given. Maps with composite keys need
+// their arity passed with `-keys namespace=N` so that the key can be
+// split.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"withoutdoing.com/m/v2/contractstate"
+)
+
+type schemaFlag contractstate.Schema
+
+func (s schemaFlag) String() string {
+	parts := make([]string, 0, len(s))
+	for ns, n := range s {
+		parts = append(parts, fmt.Sprintf("%s=%d", ns, n))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (s schemaFlag) Set(v string) error {
+	ns, n, ok := strings.Cut(v, "=")
+	if !ok {
+		return fmt.Errorf("expected namespace=N, got (%s)", v)
+	}
+	parts, err := strconv.Atoi(n)
+	if err != nil || parts < 1 {
+		return fmt.Errorf("expected a positive number of key elements, got (%s)", n)
+	}
+	s[ns] = parts
+	return nil
+}
+
+func main() {
+	format := flag.String("format", "table", "output format, one of table or json")
+	schema := schemaFlag{}
+	flag.Var(schema, "keys", "number of key elements for a map, as namespace=N (repeatable)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [export.json]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(*format, contractstate.Schema(schema), flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(format string, schema contractstate.Schema, args []string) error {
+	var in io.Reader = os.Stdin
+	switch len(args) {
+	case 0:
+	case 1:
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	default:
+		return fmt.Errorf("expected at most one export file, got %d", len(args))
+	}
+
+	export, err := contractstate.ReadExport(in)
+	if err != nil {
+		return err
+	}
+	entries, err := export.Decode(schema)
+	if err != nil {
+		return err
+	}
+	if len(export.Pagination.NextKey) != 0 {
+		fmt.Fprintln(os.Stderr, "warning: the export is only the first page of the contract's state, query it again with a larger --limit")
+	}
+
+	switch format {
+	case "table":
+		return contractstate.WriteTable(os.Stdout, entries)
+	case "json":
+		return contractstate.WriteJSON(os.Stdout, entries)
+	default:
+		return fmt.Errorf("unknown format (%s), expected table or json", format)
+	}
+}
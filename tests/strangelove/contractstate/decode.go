@@ -0,0 +1,191 @@
+// Package contractstate decodes the output of `query wasm
+// contract-state all --output json` into cw-storage-plus namespaces,
+// keys, and JSON values.
+//
+// cw-storage-plus lays out keys as follows:
+//
+//   - An `Item` is stored under its namespace verbatim.
+//   - A `Map` is stored under a two byte big-endian length prefix,
+//     the namespace, and then the key. Composite keys (tuples)
+//     length-prefix every element except the last one.
+//
+// Values are serde JSON.
+package contractstate
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// A single key/value pair as printed by the wasmd CLI.
+type Model struct {
+	Key   string `json:"key"`   // hex encoded
+	Value string `json:"value"` // base64 encoded
+}
+
+// A `QueryAllContractStateResponse` as printed by the wasmd CLI. For
+// contracts with more state than fits in a page, this is only one
+// page of it and `Pagination.NextKey` says where the next one starts.
+// See `Stream`.
+type Export struct {
+	Models     []Model    `json:"models"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// A decoded key/value pair.
+type Entry struct {
+	// The cw-storage-plus namespace, or the whole key if it
+	// doesn't look like it belongs to a map.
+	Namespace string `json:"namespace"`
+	// The elements of a map's key. Empty for items.
+	Key []string `json:"key,omitempty"`
+	// The value. If the value is not valid JSON, this is the
+	// value as a JSON string holding its base64 encoding.
+	Value json.RawMessage `json:"value"`
+	// The raw, hex encoded key as it appeared in the export.
+	RawKey string `json:"raw_key"`
+}
+
+// Schema tells the decoder how many elements make up the key of a
+// map, which isn't recoverable from the key bytes alone. Maps that
+// are not listed are assumed to have single element keys.
+type Schema map[string]int
+
+// Reads an export in the format of `query wasm contract-state all
+// --output json`.
+func ReadExport(r io.Reader) (*Export, error) {
+	var export Export
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("parsing contract state export: %w", err)
+	}
+	return &export, nil
+}
+
+// Decodes every model in the export.
+func (e *Export) Decode(schema Schema) ([]Entry, error) {
+	entries := make([]Entry, 0, len(e.Models))
+	for _, m := range e.Models {
+		entry, err := DecodeModel(m, schema)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Decodes a hex key and base64 value pair.
+func DecodeModel(m Model, schema Schema) (Entry, error) {
+	key, err := hex.DecodeString(m.Key)
+	if err != nil {
+		return Entry{}, fmt.Errorf("key (%s) is not hex: %w", m.Key, err)
+	}
+	value, err := base64.StdEncoding.DecodeString(m.Value)
+	if err != nil {
+		return Entry{}, fmt.Errorf("value for key (%s) is not base64: %w", m.Key, err)
+	}
+	return DecodeRaw(key, value, schema)
+}
+
+// Decodes a raw key and value as read from the contract's store.
+func DecodeRaw(key, value []byte, schema Schema) (Entry, error) {
+	entry := Entry{
+		RawKey: hex.EncodeToString(key),
+		Value:  decodeValue(value),
+	}
+	namespace, rest, ok := splitNamespace(key)
+	if !ok {
+		entry.Namespace = printable(key)
+		return entry, nil
+	}
+	entry.Namespace = namespace
+
+	parts := schema[namespace]
+	if parts == 0 {
+		parts = 1
+	}
+	elems, err := SplitKey(rest, parts)
+	if err != nil {
+		return Entry{}, fmt.Errorf("key (%s) in namespace (%s): %w", entry.RawKey, namespace, err)
+	}
+	for _, elem := range elems {
+		entry.Key = append(entry.Key, printable(elem))
+	}
+	return entry, nil
+}
+
+// Splits the key of a composite map entry (with the namespace
+// removed) into `parts` elements. All but the last element are
+// length-prefixed.
+func SplitKey(key []byte, parts int) ([][]byte, error) {
+	elems := make([][]byte, 0, parts)
+	for i := 0; i < parts-1; i++ {
+		elem, rest, ok := readPrefixed(key)
+		if !ok {
+			return nil, fmt.Errorf("expected %d key elements, found %d", parts, i+1)
+		}
+		elems = append(elems, elem)
+		key = rest
+	}
+	return append(elems, key), nil
+}
+
+// Returns the namespace of a map key and the remainder. Returns
+// false if the key doesn't look like it is prefixed with a
+// namespace, in which case it is most likely an `Item`.
+func splitNamespace(key []byte) (string, []byte, bool) {
+	ns, rest, ok := readPrefixed(key)
+	// namespaces are rust string literals so if it's not valid
+	// utf8 this isn't a namespace and we got unlucky with the
+	// first two bytes.
+	if !ok || len(ns) == 0 || !utf8.Valid(ns) || !isPrintable(ns) {
+		return "", nil, false
+	}
+	return string(ns), rest, true
+}
+
+func readPrefixed(key []byte) ([]byte, []byte, bool) {
+	if len(key) < 2 {
+		return nil, nil, false
+	}
+	n := int(binary.BigEndian.Uint16(key))
+	if len(key) < 2+n {
+		return nil, nil, false
+	}
+	return key[2 : 2+n], key[2+n:], true
+}
+
+func decodeValue(value []byte) json.RawMessage {
+	if json.Valid(value) {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, value); err == nil {
+			return buf.Bytes()
+		}
+	}
+	b, _ := json.Marshal(base64.StdEncoding.EncodeToString(value))
+	return b
+}
+
+// Strings are kept as-is, anything else (for example big-endian
+// integer keys) is hex encoded.
+func printable(b []byte) string {
+	if utf8.Valid(b) && isPrintable(b) {
+		return string(b)
+	}
+	return "0x" + hex.EncodeToString(b)
+}
+
+func isPrintable(b []byte) bool {
+	for _, r := range string(b) {
+		if r < 0x20 || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package contractstate
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeExport(t *testing.T) {
+	f, err := os.Open("testdata/export.json")
+	require.NoError(t, err)
+	defer f.Close()
+
+	export, err := ReadExport(f)
+	require.NoError(t, err)
+	entries, err := export.Decode(nil)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+
+	// cw2 info is an `Item` and has no key.
+	require.Equal(t, "contract_info", entries[0].Namespace)
+	require.Empty(t, entries[0].Key)
+	require.JSONEq(t, `{"contract":"crates.io:cw-ibc-example","version":"0.1.0"}`, string(entries[0].Value))
+
+	require.Equal(t, "connection_counts", entries[1].Namespace)
+	require.Equal(t, []string{"channel-0"}, entries[1].Key)
+	require.Equal(t, "2", string(entries[1].Value))
+
+	require.Equal(t, "timeout_count", entries[3].Namespace)
+	require.Equal(t, []string{"channel-0"}, entries[3].Key)
+	require.Equal(t, "1", string(entries[3].Value))
+}
+
+func TestDecodeCompositeKey(t *testing.T) {
+	// Map<(&str, u64), _> under namespace "bals".
+	key := []byte("\x00\x04bals\x00\x09channel-0\x01\x00\x00\x00\x00\x00\x00\x07")
+
+	entry, err := DecodeRaw(key, []byte(`{"a": 1}`), Schema{"bals": 2})
+	require.NoError(t, err)
+	require.Equal(t, "bals", entry.Namespace)
+	require.Equal(t, []string{"channel-0", "0x0100000000000007"}, entry.Key)
+	require.Equal(t, `{"a":1}`, string(entry.Value))
+
+	// Without a schema the whole remainder is one element.
+	entry, err = DecodeRaw(key, []byte("1"), nil)
+	require.NoError(t, err)
+	require.Len(t, entry.Key, 1)
+
+	_, err = DecodeRaw(key, []byte("1"), Schema{"bals": 3})
+	require.ErrorContains(t, err, "expected 3 key elements")
+}
+
+func TestDecodeNonJSONValue(t *testing.T) {
+	entry, err := DecodeModel(Model{Key: "6b6579", Value: "AAEC"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "key", entry.Namespace)
+	require.Equal(t, `"AAEC"`, string(entry.Value))
+
+	_, err = DecodeModel(Model{Key: "not hex", Value: ""}, nil)
+	require.Error(t, err)
+}
+
+func TestWrite(t *testing.T) {
+	entries := []Entry{{
+		Namespace: "connection_counts",
+		Key:       []string{"channel-0"},
+		Value:     json.RawMessage("2"),
+		RawKey:    "00",
+	}}
+
+	var table bytes.Buffer
+	require.NoError(t, WriteTable(&table, entries))
+	lines := strings.Split(strings.TrimSpace(table.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Equal(t, []string{"connection_counts", "channel-0", "2"}, strings.Fields(lines[1]))
+
+	var out bytes.Buffer
+	require.NoError(t, WriteJSON(&out, entries))
+	var roundtrip []Entry
+	require.NoError(t, json.Unmarshal(out.Bytes(), &roundtrip))
+	require.Equal(t, entries, roundtrip)
+}
@@ -0,0 +1,98 @@
+package contractstate
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	wasmtypes "github.com/CosmWasm/wasmd/x/wasm/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	"google.golang.org/grpc"
+)
+
+// The pagination block of a `QueryAllContractStateResponse`.
+type Pagination struct {
+	// The key to request the next page with, or empty if this is
+	// the last page.
+	NextKey []byte `json:"next_key"`
+	// Only set if the request asked for a count.
+	Total uint64 `json:"total,string"`
+}
+
+// Fetches the page of a contract's state starting at `key`, or the
+// first page if `key` is nil.
+type PageFetcher func(ctx context.Context, key []byte) (*Export, error)
+
+// Fetches pages until there are no more, decoding each model and
+// calling `visit` on it as it goes, so large contracts needn't be
+// held in memory. Stops at the first error from `fetch`, decoding,
+// or `visit`.
+func Stream(ctx context.Context, fetch PageFetcher, schema Schema, visit func(Entry) error) error {
+	var key []byte
+	seen := map[string]bool{}
+	for page := 1; ; page++ {
+		export, err := fetch(ctx, key)
+		if err != nil {
+			return fmt.Errorf("fetching page %d: %w", page, err)
+		}
+		for _, m := range export.Models {
+			entry, err := DecodeModel(m, schema)
+			if err != nil {
+				return fmt.Errorf("page %d: %w", page, err)
+			}
+			if err := visit(entry); err != nil {
+				return err
+			}
+		}
+		key = export.Pagination.NextKey
+		if len(key) == 0 {
+			return nil
+		}
+		if seen[string(key)] {
+			return fmt.Errorf("page %d: next key (%x) was already requested", page, key)
+		}
+		seen[string(key)] = true
+	}
+}
+
+// Fetches and decodes every page.
+func ReadAll(ctx context.Context, fetch PageFetcher, schema Schema) ([]Entry, error) {
+	var entries []Entry
+	err := Stream(ctx, fetch, schema, func(e Entry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	return entries, err
+}
+
+// Fetches pages of at most `limit` models (zero for the node's
+// default) of `contract`'s state over gRPC. The CLI's `--page-key`
+// flag can't be used to follow `next_key`, as cw-storage-plus keys
+// are binary and the CLI passes the flag's value through as the raw
+// key.
+func GRPCPages(conn grpc.ClientConnInterface, contract string, limit uint64) PageFetcher {
+	client := wasmtypes.NewQueryClient(conn)
+	return func(ctx context.Context, key []byte) (*Export, error) {
+		resp, err := client.AllContractState(ctx, &wasmtypes.QueryAllContractStateRequest{
+			Address:    contract,
+			Pagination: &query.PageRequest{Key: key, Limit: limit},
+		})
+		if err != nil {
+			return nil, err
+		}
+		export := &Export{Models: make([]Model, len(resp.Models))}
+		for i, m := range resp.Models {
+			export.Models[i] = Model{
+				Key:   m.Key.String(),
+				Value: base64.StdEncoding.EncodeToString(m.Value),
+			}
+		}
+		if resp.Pagination != nil {
+			export.Pagination = Pagination{
+				NextKey: append([]byte(nil), resp.Pagination.NextKey...),
+				Total:   resp.Pagination.Total,
+			}
+		}
+		return export, nil
+	}
+}
@@ -0,0 +1,149 @@
+package contractstate
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	wasmtypes "github.com/CosmWasm/wasmd/x/wasm/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Serves testdata/page{1,2,3}.json, which are testdata/export.json
+// split into pages, and records the keys it was asked for.
+type filePages struct {
+	requested []string
+}
+
+func (f *filePages) fetch(_ context.Context, key []byte) (*Export, error) {
+	f.requested = append(f.requested, string(key))
+	pages := map[string]string{
+		"":                                   "page1.json",
+		"\x00\x11connection_countschannel-1": "page2.json",
+		"\x00\x0dtimeout_countchannel-0":     "page3.json",
+	}
+	name, ok := pages[string(key)]
+	if !ok {
+		return nil, errors.New("no such page")
+	}
+	file, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadExport(file)
+}
+
+func readExportFile(t *testing.T, name string) *Export {
+	file, err := os.Open(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	defer file.Close()
+	export, err := ReadExport(file)
+	require.NoError(t, err)
+	return export
+}
+
+func TestStreamFollowsNextKey(t *testing.T) {
+	pages := &filePages{}
+	entries, err := ReadAll(context.Background(), pages.fetch, nil)
+	require.NoError(t, err)
+
+	expected, err := readExportFile(t, "export.json").Decode(nil)
+	require.NoError(t, err)
+	require.Equal(t, expected, entries)
+	require.Equal(t, []string{
+		"",
+		"\x00\x11connection_countschannel-1",
+		"\x00\x0dtimeout_countchannel-0",
+	}, pages.requested)
+}
+
+func TestStreamStopsOnError(t *testing.T) {
+	pages := &filePages{}
+	visited := 0
+	err := Stream(context.Background(), pages.fetch, nil, func(Entry) error {
+		visited++
+		if visited == 3 {
+			return errors.New("stop")
+		}
+		return nil
+	})
+	require.EqualError(t, err, "stop")
+	require.Len(t, pages.requested, 2)
+
+	_, err = ReadAll(context.Background(), func(context.Context, []byte) (*Export, error) {
+		return nil, errors.New("node down")
+	}, nil)
+	require.EqualError(t, err, "fetching page 1: node down")
+}
+
+func TestStreamDetectsLoops(t *testing.T) {
+	page := readExportFile(t, "page1.json")
+	_, err := ReadAll(context.Background(), func(context.Context, []byte) (*Export, error) {
+		return page, nil
+	}, nil)
+	require.ErrorContains(t, err, "page 2: next key (0011636f6e6e656374696f6e5f636f756e74736368616e6e656c2d31) was already requested")
+}
+
+// Serves the models in testdata/export.json `limit` at a time.
+type stateServer struct {
+	wasmtypes.UnimplementedQueryServer
+	models []wasmtypes.Model
+}
+
+func (s *stateServer) AllContractState(_ context.Context, req *wasmtypes.QueryAllContractStateRequest) (*wasmtypes.QueryAllContractStateResponse, error) {
+	start := 0
+	if req.Pagination.Key != nil {
+		for start < len(s.models) && string(s.models[start].Key) != string(req.Pagination.Key) {
+			start++
+		}
+	}
+	end := start + int(req.Pagination.Limit)
+	resp := &wasmtypes.QueryAllContractStateResponse{Pagination: &query.PageResponse{}}
+	if end < len(s.models) {
+		resp.Pagination.NextKey = s.models[end].Key
+	} else {
+		end = len(s.models)
+	}
+	resp.Models = s.models[start:end]
+	return resp, nil
+}
+
+func TestGRPCPages(t *testing.T) {
+	server := &stateServer{}
+	for _, m := range readExportFile(t, "export.json").Models {
+		key, err := hex.DecodeString(m.Key)
+		require.NoError(t, err)
+		value, err := base64.StdEncoding.DecodeString(m.Value)
+		require.NoError(t, err)
+		server.models = append(server.models, wasmtypes.Model{Key: key, Value: value})
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	wasmtypes.RegisterQueryServer(s, server)
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	entries, err := ReadAll(context.Background(), GRPCPages(conn, "juno1contract", 3), nil)
+	require.NoError(t, err)
+	expected, err := readExportFile(t, "export.json").Decode(nil)
+	require.NoError(t, err)
+	require.Equal(t, expected, entries)
+}
@@ -0,0 +1,23 @@
+package strangelove
+
+import (
+	"context"
+	"testing"
+
+	"withoutdoing.com/harness"
+	"withoutdoing.com/m/v2/helper"
+)
+
+// Runs the scenarios that `simtests.TestHarnessScenarios` runs on
+// simulated chains against real ones.
+func TestHarnessScenarios(t *testing.T) {
+	t.Parallel()
+	for _, s := range harness.Scenarios {
+		s := s
+		t.Run(s.Name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			s.Run(ctx, t, helper.NewInterchainHarness(ctx, t))
+		})
+	}
+}
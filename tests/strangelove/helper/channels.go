@@ -0,0 +1,101 @@
+package helper
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/strangelove-ventures/interchaintest/v4/ibc"
+)
+
+// The two ends of a channel.
+type ChannelPair struct {
+	// The channel ID on the chain the channels were listed for.
+	Local string
+	// The channel ID on the counterparty chain.
+	Remote string
+}
+
+// Finds the channel between `port` on the chain `channels` were
+// listed for (with `Relayer.GetChannels`) and `counterpartyPort`.
+// Relayers don't list channels in any particular order, so use this
+// instead of indexing into the list.
+//
+// If a channel was closed and another opened between the same ports
+// the open one is returned. Errors if there is no such channel, or
+// if more than one is open. The first error is an `ErrNoChannel`.
+func FindChannel(channels []ibc.ChannelOutput, port, counterpartyPort string) (ChannelPair, error) {
+	var matches, open []ibc.ChannelOutput
+	for _, c := range channels {
+		if c.PortID == port && c.Counterparty.PortID == counterpartyPort {
+			matches = append(matches, c)
+			if c.State == "STATE_OPEN" {
+				open = append(open, c)
+			}
+		}
+	}
+	switch {
+	case len(matches) == 1:
+		return pair(matches[0]), nil
+	case len(open) == 1:
+		return pair(open[0]), nil
+	case len(matches) == 0:
+		return ChannelPair{}, noChannelError(fmt.Sprintf("no channel between %s and %s, found: %s", port, counterpartyPort, describeChannels(channels)))
+	case len(open) == 0:
+		return ChannelPair{}, noChannelError(fmt.Sprintf("no open channel between %s and %s, found: %s", port, counterpartyPort, describeChannels(matches)))
+	default:
+		return ChannelPair{}, fmt.Errorf("%d open channels between %s and %s: %s", len(open), port, counterpartyPort, describeChannels(open))
+	}
+}
+
+// Parses the output of `rly q channels`, which is one JSON channel
+// per line.
+func ParseChannels(r io.Reader) ([]ibc.ChannelOutput, error) {
+	var channels []ibc.ChannelOutput
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var channel ibc.ChannelOutput
+		if err := json.Unmarshal(scanner.Bytes(), &channel); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		channels = append(channels, channel)
+	}
+	return channels, scanner.Err()
+}
+
+// What `FindChannel` errors with when there's no channel, or none
+// of the channels are open, as opposed to when it can't tell which
+// channel is meant.
+var ErrNoChannel = errors.New("no channel")
+
+type noChannelError string
+
+func (e noChannelError) Error() string {
+	return string(e)
+}
+
+func (e noChannelError) Is(target error) bool {
+	return target == ErrNoChannel
+}
+
+func pair(c ibc.ChannelOutput) ChannelPair {
+	return ChannelPair{Local: c.ChannelID, Remote: c.Counterparty.ChannelID}
+}
+
+func describeChannels(channels []ibc.ChannelOutput) string {
+	if len(channels) == 0 {
+		return "nothing"
+	}
+	described := make([]string, len(channels))
+	for i, c := range channels {
+		described[i] = fmt.Sprintf("%s (%s) -> %s (%s) %s", c.ChannelID, c.PortID, c.Counterparty.ChannelID, c.Counterparty.PortID, c.State)
+	}
+	return strings.Join(described, ", ")
+}
@@ -0,0 +1,71 @@
+package helper
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/strangelove-ventures/interchaintest/v4/ibc"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	left  = "wasm.juno14hj2tavq8fpesdwxxcu44rty3hh90vhujrvcmstl4zr3txmfvw9skjuwg8"
+	right = "wasm.juno1nc5tatafv6eyq7llkr2gv50ff9e22mnf70qgjlv737ktmt4eswrq68ev2p"
+)
+
+func readChannels(t *testing.T, name string) []ibc.ChannelOutput {
+	f, err := os.Open(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	defer f.Close()
+	channels, err := ParseChannels(f)
+	require.NoError(t, err)
+	return channels
+}
+
+func TestFindChannel(t *testing.T) {
+	channels := readChannels(t, "channels.jsonl")
+	require.Len(t, channels, 2)
+
+	pair, err := FindChannel(channels, left, right)
+	require.NoError(t, err)
+	require.Equal(t, ChannelPair{Local: "channel-1", Remote: "channel-1"}, pair)
+
+	pair, err = FindChannel(channels, "transfer", "transfer")
+	require.NoError(t, err)
+	require.Equal(t, ChannelPair{Local: "channel-0", Remote: "channel-0"}, pair)
+}
+
+func TestFindChannelPrefersOpen(t *testing.T) {
+	pair, err := FindChannel(readChannels(t, "channels_reopened.jsonl"), left, right)
+	require.NoError(t, err)
+	require.Equal(t, ChannelPair{Local: "channel-2", Remote: "channel-3"}, pair)
+}
+
+func TestFindChannelErrors(t *testing.T) {
+	channels := readChannels(t, "channels.jsonl")
+
+	// ports the wrong way around.
+	_, err := FindChannel(channels, right, left)
+	require.ErrorContains(t, err, "no channel between "+right+" and "+left+", found: channel-0 (transfer) -> channel-0 (transfer) STATE_OPEN, channel-1")
+
+	_, err = FindChannel(nil, left, right)
+	require.ErrorContains(t, err, "found: nothing")
+	require.ErrorIs(t, err, ErrNoChannel)
+
+	_, err = FindChannel(readChannels(t, "channels_ambiguous.jsonl"), left, right)
+	require.ErrorContains(t, err, "2 open channels between "+left+" and "+right+": channel-1")
+	require.NotErrorIs(t, err, ErrNoChannel)
+
+	closed := readChannels(t, "channels_reopened.jsonl")[:2]
+	closed = append(closed, closed[1])
+	_, err = FindChannel(closed, left, right)
+	require.ErrorContains(t, err, "no open channel between")
+	require.ErrorIs(t, err, ErrNoChannel)
+}
+
+func TestParseChannelsError(t *testing.T) {
+	_, err := ParseChannels(strings.NewReader("\n{\"state\":\"STATE_OPEN\"}\nnot json\n"))
+	require.ErrorContains(t, err, "line 3:")
+}
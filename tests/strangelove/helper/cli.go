@@ -0,0 +1,166 @@
+package helper
+
+import (
+	"strconv"
+
+	"github.com/strangelove-ventures/interchaintest/v4/ibc"
+)
+
+// Builds argv for a chain's CLI, for use with `ibc.Chain.Exec`. Every
+// command asks for `--output json`.
+type CLI struct {
+	Bin     string
+	ChainID string
+	// The RPC address of the node to talk to.
+	Node string
+	// The CLI's home directory, which holds the keyring.
+	Home string
+	// Used to pay for transactions.
+	GasPrices     string
+	GasAdjustment float64
+}
+
+// A CLI for an interchaintest chain.
+func NewCLI(chain ibc.Chain) CLI {
+	config := chain.Config()
+	return CLI{
+		Bin:           config.Bin,
+		ChainID:       config.ChainID,
+		Node:          chain.GetRPCAddress(),
+		Home:          chain.HomeDir(),
+		GasPrices:     config.GasPrices,
+		GasAdjustment: config.GasAdjustment,
+	}
+}
+
+// `query wasm contract-state all`. A `limit` of zero uses the node's
+// default page size.
+func (c CLI) WasmContractStateAll(contract string, limit uint64) []string {
+	args := []string{"wasm", "contract-state", "all", contract}
+	if limit != 0 {
+		args = append(args, "--limit", strconv.FormatUint(limit, 10))
+	}
+	return c.query(args...)
+}
+
+// `query wasm contract-state smart`.
+func (c CLI) WasmContractStateSmart(contract, msg string) []string {
+	return c.query("wasm", "contract-state", "smart", contract, msg)
+}
+
+// `query wasm contract-state raw` with a hex encoded key.
+func (c CLI) WasmContractStateRaw(contract, hexKey string) []string {
+	return c.query("wasm", "contract-state", "raw", contract, hexKey, "--hex")
+}
+
+// `query wasm contract`, which includes the contract's IBC port.
+func (c CLI) WasmContract(contract string) []string {
+	return c.query("wasm", "contract", contract)
+}
+
+// `query ibc channel channels`.
+func (c CLI) IBCChannels() []string {
+	return c.query("ibc", "channel", "channels")
+}
+
+// `query ibc channel end`.
+func (c CLI) IBCChannel(port, channel string) []string {
+	return c.query("ibc", "channel", "end", port, channel)
+}
+
+// `query ibc channel packet-commitments`, the packets sent over the
+// channel that haven't been acknowledged or timed out yet.
+func (c CLI) IBCPacketCommitments(port, channel string) []string {
+	return c.query("ibc", "channel", "packet-commitments", port, channel)
+}
+
+// `query ibc client state`.
+func (c CLI) IBCClientState(clientID string) []string {
+	return c.query("ibc", "client", "state", clientID)
+}
+
+// `query ibc client status`, which is "Active", "Expired" or
+// "Frozen".
+func (c CLI) IBCClientStatus(clientID string) []string {
+	return c.query("ibc", "client", "status", clientID)
+}
+
+// `query ibc connection connections`.
+func (c CLI) IBCConnections() []string {
+	return c.query("ibc", "connection", "connections")
+}
+
+// `query ibc connection end`.
+func (c CLI) IBCConnection(connectionID string) []string {
+	return c.query("ibc", "connection", "end", connectionID)
+}
+
+// `query bank balances`, for all denoms if `denom` is empty.
+func (c CLI) BankBalances(address, denom string) []string {
+	args := []string{"bank", "balances", address}
+	if denom != "" {
+		args = append(args, "--denom", denom)
+	}
+	return c.query(args...)
+}
+
+// `tx wasm store`, signed by the key named `from`.
+func (c CLI) WasmStore(from, wasmFile string) []string {
+	return c.tx(from, "wasm", "store", wasmFile)
+}
+
+// `tx wasm instantiate`. The contract has no admin if `admin` is
+// empty.
+func (c CLI) WasmInstantiate(from string, codeID uint64, msg, label, admin string) []string {
+	args := []string{"wasm", "instantiate", strconv.FormatUint(codeID, 10), msg, "--label", label}
+	if admin == "" {
+		args = append(args, "--no-admin")
+	} else {
+		args = append(args, "--admin", admin)
+	}
+	return c.tx(from, args...)
+}
+
+// `tx wasm execute`, sending `amount` (for example "100ujuno") along
+// if it isn't empty.
+func (c CLI) WasmExecute(from, contract, msg, amount string) []string {
+	args := []string{"wasm", "execute", contract, msg}
+	if amount != "" {
+		args = append(args, "--amount", amount)
+	}
+	return c.tx(from, args...)
+}
+
+// `tx bank send`.
+func (c CLI) BankSend(from, to, amount string) []string {
+	return c.tx(from, "bank", "send", from, to, amount)
+}
+
+func (c CLI) query(args ...string) []string {
+	cmd := append([]string{c.Bin, "query"}, args...)
+	return append(cmd,
+		"--node", c.Node,
+		"--home", c.Home,
+		"--chain-id", c.ChainID,
+		"--output", "json",
+	)
+}
+
+func (c CLI) tx(from string, args ...string) []string {
+	cmd := append([]string{c.Bin, "tx"}, args...)
+	cmd = append(cmd,
+		"--from", from,
+		"--node", c.Node,
+		"--home", c.Home,
+		"--chain-id", c.ChainID,
+		"--keyring-backend", "test",
+		"--gas", "auto",
+	)
+	if c.GasPrices != "" {
+		cmd = append(cmd, "--gas-prices", c.GasPrices)
+	}
+	if c.GasAdjustment != 0 {
+		cmd = append(cmd, "--gas-adjustment", strconv.FormatFloat(c.GasAdjustment, 'f', -1, 64))
+	}
+	return append(cmd, "--broadcast-mode", "block", "--yes", "--output", "json")
+}
@@ -0,0 +1,78 @@
+package helper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/strangelove-ventures/interchaintest/v4/ibc"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeChain struct {
+	ibc.Chain
+}
+
+func (fakeChain) Config() ibc.ChainConfig {
+	return ibc.ChainConfig{
+		Bin:           "junod",
+		ChainID:       "juno2",
+		GasPrices:     "0.00ujuno",
+		GasAdjustment: 2.0,
+	}
+}
+func (fakeChain) GetRPCAddress() string { return "http://juno2-val-0:26657" }
+func (fakeChain) HomeDir() string       { return "/var/cosmos-chain/juno" }
+
+const (
+	queryFlags = " --node http://juno2-val-0:26657 --home /var/cosmos-chain/juno --chain-id juno2 --output json"
+	txFlags    = " --node http://juno2-val-0:26657 --home /var/cosmos-chain/juno --chain-id juno2" +
+		" --keyring-backend test --gas auto --gas-prices 0.00ujuno --gas-adjustment 2" +
+		" --broadcast-mode block --yes --output json"
+)
+
+func TestCLIQueries(t *testing.T) {
+	cli := NewCLI(fakeChain{})
+	for expected, argv := range map[string][]string{
+		"junod query wasm contract-state all juno1c" + queryFlags:                       cli.WasmContractStateAll("juno1c", 0),
+		"junod query wasm contract-state all juno1c --limit 2" + queryFlags:             cli.WasmContractStateAll("juno1c", 2),
+		"junod query wasm contract-state smart juno1c {}" + queryFlags:                  cli.WasmContractStateSmart("juno1c", "{}"),
+		"junod query wasm contract-state raw juno1c 00ff --hex" + queryFlags:            cli.WasmContractStateRaw("juno1c", "00ff"),
+		"junod query wasm contract juno1c" + queryFlags:                                 cli.WasmContract("juno1c"),
+		"junod query ibc channel channels" + queryFlags:                                 cli.IBCChannels(),
+		"junod query ibc channel end wasm.juno1c channel-1" + queryFlags:                cli.IBCChannel("wasm.juno1c", "channel-1"),
+		"junod query ibc channel packet-commitments wasm.juno1c channel-1" + queryFlags: cli.IBCPacketCommitments("wasm.juno1c", "channel-1"),
+		"junod query ibc client state 07-tendermint-0" + queryFlags:                     cli.IBCClientState("07-tendermint-0"),
+		"junod query ibc client status 07-tendermint-0" + queryFlags:                    cli.IBCClientStatus("07-tendermint-0"),
+		"junod query ibc connection connections" + queryFlags:                           cli.IBCConnections(),
+		"junod query ibc connection end connection-0" + queryFlags:                      cli.IBCConnection("connection-0"),
+		"junod query bank balances juno1u" + queryFlags:                                 cli.BankBalances("juno1u", ""),
+		"junod query bank balances juno1u --denom ujuno" + queryFlags:                   cli.BankBalances("juno1u", "ujuno"),
+	} {
+		require.Equal(t, expected, strings.Join(argv, " "))
+	}
+}
+
+func TestCLITxs(t *testing.T) {
+	cli := NewCLI(fakeChain{})
+	for expected, argv := range map[string][]string{
+		"junod tx wasm store c.wasm --from u" + txFlags:                              cli.WasmStore("u", "c.wasm"),
+		"junod tx wasm instantiate 1 {} --label l --no-admin --from u" + txFlags:     cli.WasmInstantiate("u", 1, "{}", "l", ""),
+		"junod tx wasm instantiate 1 {} --label l --admin juno1a --from u" + txFlags: cli.WasmInstantiate("u", 1, "{}", "l", "juno1a"),
+		"junod tx wasm execute juno1c {} --from u" + txFlags:                         cli.WasmExecute("u", "juno1c", "{}", ""),
+		"junod tx wasm execute juno1c {} --amount 10ujuno --from u" + txFlags:        cli.WasmExecute("u", "juno1c", "{}", "10ujuno"),
+		"junod tx bank send u juno1v 10ujuno --from u" + txFlags:                     cli.BankSend("u", "juno1v", "10ujuno"),
+	} {
+		require.Equal(t, expected, strings.Join(argv, " "))
+	}
+}
+
+// Messages are single arguments even if they contain spaces.
+func TestCLIKeepsArgumentsWhole(t *testing.T) {
+	msg := `{"increment": {"channel": "channel-1"}}`
+	argv := CLI{Bin: "junod"}.WasmExecute("u", "juno1c", msg, "")
+	require.Equal(t, msg, argv[5])
+
+	// no gas price or adjustment flags if they aren't configured.
+	require.NotContains(t, argv, "--gas-prices")
+	require.NotContains(t, argv, "--gas-adjustment")
+}
@@ -1,5 +1,7 @@
 package helper
 
+import "withoutdoing.com/m/v2/contractstate"
+
 type QueryResponse struct {
 	Data GetCountQuery `json:"data"`
 }
@@ -17,14 +19,13 @@ type GetCount struct {
 	Channel string `json:"channel"`
 }
 
-type KvPair struct {
-	Key   string // hex encoded string
-	Value string // b64 encoded json
-}
+// Deprecated: use `contractstate.Model`.
+type KvPair = contractstate.Model
 
-type ContractStateResp struct {
-	Models []KvPair
-}
+// Deprecated: use `contractstate.Export`, which also has the
+// response's pagination, and `contractstate.Stream` to read contracts
+// with more than one page of state.
+type ContractStateResp = contractstate.Export
 
 func Ptr[T any](v T) *T {
 	return &v
@@ -0,0 +1,226 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/strangelove-ventures/interchaintest/v4"
+	"github.com/strangelove-ventures/interchaintest/v4/chain/cosmos"
+	"github.com/strangelove-ventures/interchaintest/v4/chain/cosmos/wasm"
+	"github.com/strangelove-ventures/interchaintest/v4/ibc"
+	"github.com/strangelove-ventures/interchaintest/v4/testreporter"
+	"go.uber.org/zap/zaptest"
+	"withoutdoing.com/harness"
+)
+
+// Where `../../justfile` places the compiled contract, relative to
+// the strangelove tests.
+const WasmFile = "../wasms/cw_ibc_example.wasm"
+
+// How many blocks `InterchainHarness` waits for the relayer before
+// giving up.
+const relayBlocks = 20
+
+// Runs the scenarios in `withoutdoing.com/harness` on two juno chains
+// in docker with a relayer running between them.
+type InterchainHarness struct {
+	Chains   [2]*cosmos.CosmosChain
+	Users    [2]*ibc.Wallet
+	Relayer  ibc.Relayer
+	Reporter *testreporter.RelayerExecReporter
+	// Set by `Deploy`.
+	Contracts [2]string
+	// Set by `OpenChannel`. `Channel.Local` is on chain A.
+	Channel ChannelPair
+
+	// packets sent from each side.
+	sent [2]uint32
+}
+
+var _ harness.Harness = (*InterchainHarness)(nil)
+
+// The path the harness' relayer relays over.
+const interchainPath = "juno-juno"
+
+// Starts two chains and a relayer between them. Everything is torn
+// down when the test finishes.
+func NewInterchainHarness(ctx context.Context, t *testing.T) *InterchainHarness {
+	// w/ no gas adjustment, storing contracts fails w/
+	// out-of-gas.
+	spec := func(name string) *interchaintest.ChainSpec {
+		return &interchaintest.ChainSpec{
+			Name:      "juno",
+			ChainName: name,
+			Version:   "latest",
+			ChainConfig: ibc.ChainConfig{
+				GasPrices:      "0.00ujuno",
+				GasAdjustment:  2.0,
+				EncodingConfig: wasm.WasmEncoding(),
+			},
+			NumValidators: Ptr(1),
+			NumFullNodes:  Ptr(0),
+		}
+	}
+	cf := interchaintest.NewBuiltinChainFactory(zaptest.NewLogger(t), []*interchaintest.ChainSpec{spec("juno1"), spec("juno2")})
+	chains, err := cf.Chains(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	left, right := chains[0], chains[1]
+
+	client, network := interchaintest.DockerSetup(t)
+	relayer := interchaintest.NewBuiltinRelayerFactory(
+		ibc.CosmosRly,
+		zaptest.NewLogger(t),
+	).Build(t, client, network)
+
+	ic := interchaintest.NewInterchain().
+		AddChain(left).
+		AddChain(right).
+		AddRelayer(relayer, "relayer").
+		AddLink(interchaintest.InterchainLink{
+			Chain1:  left,
+			Chain2:  right,
+			Relayer: relayer,
+			Path:    interchainPath,
+		})
+
+	erp := testreporter.NewNopReporter().RelayerExecReporter(t)
+	err = ic.Build(ctx, erp, interchaintest.InterchainBuildOptions{
+		TestName:  t.Name(),
+		Client:    client,
+		NetworkID: network,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = ic.Close()
+	})
+
+	if err := relayer.StartRelayer(ctx, erp, interchainPath); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := relayer.StopRelayer(ctx, erp); err != nil {
+			t.Logf("couldn't stop relayer: %s", err)
+		}
+	})
+
+	users := interchaintest.GetAndFundTestUsers(t, ctx, "default", int64(10_000_000), left, right)
+	return &InterchainHarness{
+		Chains:   [2]*cosmos.CosmosChain{left.(*cosmos.CosmosChain), right.(*cosmos.CosmosChain)},
+		Users:    [2]*ibc.Wallet{users[0], users[1]},
+		Relayer:  relayer,
+		Reporter: erp,
+	}
+}
+
+func (h *InterchainHarness) Deploy(ctx context.Context) error {
+	for i, chain := range h.Chains {
+		codeID, err := chain.StoreContract(ctx, h.Users[i].KeyName, WasmFile)
+		if err != nil {
+			return fmt.Errorf("storing contract on %s: %w", chain.Config().ChainID, err)
+		}
+		h.Contracts[i], err = chain.InstantiateContract(ctx, h.Users[i].KeyName, codeID, "{}", true)
+		if err != nil {
+			return fmt.Errorf("instantiating contract on %s: %w", chain.Config().ChainID, err)
+		}
+	}
+	return nil
+}
+
+func (h *InterchainHarness) OpenChannel(ctx context.Context) error {
+	if h.Contracts[0] == "" {
+		return errors.New("contracts have not been deployed")
+	}
+	portA, portB := "wasm."+h.Contracts[0], "wasm."+h.Contracts[1]
+	err := h.Relayer.CreateChannel(ctx, h.Reporter, interchainPath, ibc.CreateChannelOptions{
+		SourcePortName: portA,
+		DestPortName:   portB,
+		Order:          ibc.Unordered,
+		Version:        "counter-1",
+	})
+	if err != nil {
+		return err
+	}
+	// wait for the relayer to see the channel.
+	return WaitUntil(ctx, h.Chains[0], ChannelFound(h.Relayer, h.Reporter, h.Chains[0].Config().ChainID, portA, portB, &h.Channel), relayBlocks)
+}
+
+func (h *InterchainHarness) Increment(ctx context.Context, side harness.Side) error {
+	channel, err := h.channel(side)
+	if err != nil {
+		return err
+	}
+	msg := fmt.Sprintf(`{"increment":{"channel":%q}}`, channel)
+	if _, err := h.Chains[side].ExecuteContract(ctx, h.Users[side].KeyName, h.Contracts[side], msg); err != nil {
+		return err
+	}
+	h.sent[side]++
+	return nil
+}
+
+// Waits for each side's count to reach the number of packets the
+// other side has sent, which happens once the relayer has delivered
+// them, and then for each side to have no packet commitments left,
+// which happens once the relayer has delivered their
+// acknowledgements.
+func (h *InterchainHarness) Relay(ctx context.Context) error {
+	for _, side := range []harness.Side{harness.A, harness.B} {
+		channel, err := h.channel(side)
+		if err != nil {
+			return err
+		}
+		received := CountReaches(h.Chains[side], h.Contracts[side], channel, h.sent[side.Other()])
+		if err := WaitUntil(ctx, h.Chains[side], received, relayBlocks); err != nil {
+			return fmt.Errorf("waiting for packets to reach %s: %w", side, err)
+		}
+	}
+	for _, side := range []harness.Side{harness.A, harness.B} {
+		channel, err := h.channel(side)
+		if err != nil {
+			return err
+		}
+		acked := CommitmentsCleared(h.Chains[side], NewCLI(h.Chains[side]), "wasm."+h.Contracts[side], channel)
+		if err := WaitUntil(ctx, h.Chains[side], acked, relayBlocks); err != nil {
+			return fmt.Errorf("waiting for acknowledgements to reach %s: %w", side, err)
+		}
+	}
+	return nil
+}
+
+func (h *InterchainHarness) Count(ctx context.Context, side harness.Side) (uint32, error) {
+	channel, err := h.channel(side)
+	if err != nil {
+		return 0, err
+	}
+	return h.query(ctx, side, QueryMsg{GetCount: &GetCount{Channel: channel}})
+}
+
+func (h *InterchainHarness) TimeoutCount(ctx context.Context, side harness.Side) (uint32, error) {
+	channel, err := h.channel(side)
+	if err != nil {
+		return 0, err
+	}
+	return h.query(ctx, side, QueryMsg{GetTimeoutCount: &GetCount{Channel: channel}})
+}
+
+func (h *InterchainHarness) query(ctx context.Context, side harness.Side, msg QueryMsg) (uint32, error) {
+	var resp QueryResponse
+	err := h.Chains[side].QueryContract(ctx, h.Contracts[side], msg, &resp)
+	return resp.Data.Count, err
+}
+
+// The channel ID on `side`.
+func (h *InterchainHarness) channel(side harness.Side) (string, error) {
+	if h.Channel.Local == "" {
+		return "", errors.New("no channel has been opened")
+	}
+	if side == harness.A {
+		return h.Channel.Local, nil
+	}
+	return h.Channel.Remote, nil
+}
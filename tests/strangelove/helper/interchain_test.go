@@ -0,0 +1,26 @@
+package helper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"withoutdoing.com/harness"
+)
+
+// The parts of the harness that don't need chains.
+func TestInterchainHarnessOrdering(t *testing.T) {
+	ctx := context.Background()
+	h := &InterchainHarness{}
+	require.EqualError(t, h.OpenChannel(ctx), "contracts have not been deployed")
+	require.EqualError(t, h.Increment(ctx, harness.A), "no channel has been opened")
+	_, err := h.Count(ctx, harness.B)
+	require.EqualError(t, err, "no channel has been opened")
+
+	h.Channel = ChannelPair{Local: "channel-1", Remote: "channel-4"}
+	a, err := h.channel(harness.A)
+	require.NoError(t, err)
+	b, err := h.channel(harness.B)
+	require.NoError(t, err)
+	require.Equal(t, []string{"channel-1", "channel-4"}, []string{a, b})
+}
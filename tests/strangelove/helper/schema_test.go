@@ -0,0 +1,12 @@
+package helper
+
+import (
+	"testing"
+
+	"withoutdoing.com/harness/msgschema"
+)
+
+func TestMessagesMatchSchema(t *testing.T) {
+	msgschema.Require(t, "../../../schema/raw/query.json", QueryMsg{})
+	msgschema.Require(t, "../../../schema/raw/response_to_get_count.json", GetCountQuery{Count: 1})
+}
@@ -0,0 +1,161 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	clienttypes "github.com/cosmos/ibc-go/v4/modules/core/02-client/types"
+	"github.com/strangelove-ventures/interchaintest/v4/ibc"
+)
+
+// Anything with a block height, such as an `ibc.Chain`.
+type HeightSource interface {
+	Height(ctx context.Context) (uint64, error)
+}
+
+// A condition to wait for. Returns true once the condition holds.
+type Predicate func(ctx context.Context) (bool, error)
+
+// How often `WaitUntil` checks for a new block.
+var PollInterval = 500 * time.Millisecond
+
+// Checks `predicate` now and then once per new block on `heights`
+// until it holds, which is faster and less flaky than waiting a
+// fixed number of blocks or a fixed amount of time. Errors if it
+// doesn't hold within `maxBlocks` blocks, or if `predicate` or
+// `heights` error.
+func WaitUntil(ctx context.Context, heights HeightSource, predicate Predicate, maxBlocks uint64) error {
+	start, err := heights.Height(ctx)
+	if err != nil {
+		return fmt.Errorf("querying height: %w", err)
+	}
+	last := start
+	for {
+		ok, err := predicate(ctx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		if last-start >= maxBlocks {
+			return fmt.Errorf("condition did not hold after %d blocks (heights %d to %d)", maxBlocks, start, last)
+		}
+		if last, err = nextHeight(ctx, heights, last); err != nil {
+			return err
+		}
+	}
+}
+
+// Waits for the height to go past `last` and returns the new
+// height.
+func nextHeight(ctx context.Context, heights HeightSource, last uint64) (uint64, error) {
+	for {
+		height, err := heights.Height(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("querying height: %w", err)
+		}
+		if height > last {
+			return height, nil
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(PollInterval):
+		}
+	}
+}
+
+// Something that can query contracts, such as a
+// `*cosmos.CosmosChain`.
+type ContractQuerier interface {
+	QueryContract(ctx context.Context, contractAddress string, query any, response any) error
+}
+
+// Holds once `contract` has received at least `n` packets over
+// `channel`.
+func CountReaches(chain ContractQuerier, contract, channel string, n uint32) Predicate {
+	return countReaches(chain, contract, QueryMsg{GetCount: &GetCount{Channel: channel}}, n)
+}
+
+// Holds once at least `n` packets `contract` sent over `channel`
+// have timed out.
+func TimeoutCountReaches(chain ContractQuerier, contract, channel string, n uint32) Predicate {
+	return countReaches(chain, contract, QueryMsg{GetTimeoutCount: &GetCount{Channel: channel}}, n)
+}
+
+func countReaches(chain ContractQuerier, contract string, query QueryMsg, n uint32) Predicate {
+	return func(ctx context.Context) (bool, error) {
+		var resp QueryResponse
+		if err := chain.QueryContract(ctx, contract, query, &resp); err != nil {
+			return false, err
+		}
+		return resp.Data.Count >= n, nil
+	}
+}
+
+// Holds once the light client's status is `status`, for example
+// "Expired".
+func ClientStatusIs(client clienttypes.QueryClient, clientID, status string) Predicate {
+	return func(ctx context.Context) (bool, error) {
+		resp, err := client.ClientStatus(ctx, &clienttypes.QueryClientStatusRequest{ClientId: clientID})
+		if err != nil {
+			return false, fmt.Errorf("querying status of %s: %w", clientID, err)
+		}
+		return resp.Status == status, nil
+	}
+}
+
+// Something that can list channels, such as an `ibc.Relayer`.
+type ChannelLister interface {
+	GetChannels(ctx context.Context, rep ibc.RelayerExecReporter, chainID string) ([]ibc.ChannelOutput, error)
+}
+
+// Holds once `relayer` lists a channel between `port` on `chainID`
+// and `counterpartyPort` (see `FindChannel`), and stores it in
+// `channel`. Errors straight away if more than one channel is open
+// between the ports.
+func ChannelFound(relayer ChannelLister, rep ibc.RelayerExecReporter, chainID, port, counterpartyPort string, channel *ChannelPair) Predicate {
+	return func(ctx context.Context) (bool, error) {
+		channels, err := relayer.GetChannels(ctx, rep, chainID)
+		if err != nil {
+			return false, err
+		}
+		found, err := FindChannel(channels, port, counterpartyPort)
+		if errors.Is(err, ErrNoChannel) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		*channel = found
+		return true, nil
+	}
+}
+
+// Something that can run commands on a node, such as an `ibc.Chain`.
+type Executor interface {
+	Exec(ctx context.Context, cmd []string, env []string) (stdout, stderr []byte, err error)
+}
+
+// Holds once no packets sent over `channel` on `port` are waiting
+// to be acknowledged or timed out, which is once the relayer has
+// delivered their acknowledgements.
+func CommitmentsCleared(chain Executor, cli CLI, port, channel string) Predicate {
+	return func(ctx context.Context) (bool, error) {
+		stdout, stderr, err := chain.Exec(ctx, cli.IBCPacketCommitments(port, channel), nil)
+		if err != nil {
+			return false, fmt.Errorf("querying packet commitments of %s: %w: %s", channel, err, stderr)
+		}
+		var resp struct {
+			Commitments []json.RawMessage `json:"commitments"`
+		}
+		if err := json.Unmarshal(stdout, &resp); err != nil {
+			return false, fmt.Errorf("parsing packet commitments of %s: %w", channel, err)
+		}
+		return len(resp.Commitments) == 0, nil
+	}
+}
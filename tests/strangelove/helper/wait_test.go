@@ -0,0 +1,193 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	clienttypes "github.com/cosmos/ibc-go/v4/modules/core/02-client/types"
+	"github.com/strangelove-ventures/interchaintest/v4/ibc"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// A chain which makes a new block every `every` height queries.
+type fakeHeights struct {
+	height  uint64
+	every   int
+	queries int
+	err     error
+}
+
+func (f *fakeHeights) Height(context.Context) (uint64, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	f.queries++
+	if f.queries%f.every == 0 {
+		f.height++
+	}
+	return f.height, nil
+}
+
+// A contract whose count goes up by one every query.
+type fakeContract struct {
+	count uint32
+	last  QueryMsg
+}
+
+func (f *fakeContract) QueryContract(_ context.Context, _ string, query any, response any) error {
+	f.last = query.(QueryMsg)
+	f.count++
+	bz, err := json.Marshal(QueryResponse{Data: GetCountQuery{Count: f.count}})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bz, response)
+}
+
+type fakeClients struct {
+	clienttypes.QueryClient
+	statuses []string
+}
+
+func (f *fakeClients) ClientStatus(context.Context, *clienttypes.QueryClientStatusRequest, ...grpc.CallOption) (*clienttypes.QueryClientStatusResponse, error) {
+	status := f.statuses[0]
+	if len(f.statuses) > 1 {
+		f.statuses = f.statuses[1:]
+	}
+	return &clienttypes.QueryClientStatusResponse{Status: status}, nil
+}
+
+// A relayer which lists no channels until its second query.
+type fakeRelayer struct {
+	channels []ibc.ChannelOutput
+	queries  int
+}
+
+func (f *fakeRelayer) GetChannels(context.Context, ibc.RelayerExecReporter, string) ([]ibc.ChannelOutput, error) {
+	f.queries++
+	if f.queries < 2 {
+		return nil, nil
+	}
+	return f.channels, nil
+}
+
+// A node whose packet commitments are `commitments`, one per
+// command run.
+type fakeNode struct {
+	commitments []string
+	last        []string
+}
+
+func (f *fakeNode) Exec(_ context.Context, cmd []string, _ []string) ([]byte, []byte, error) {
+	f.last = cmd
+	out := f.commitments[0]
+	if len(f.commitments) > 1 {
+		f.commitments = f.commitments[1:]
+	}
+	return []byte(out), nil, nil
+}
+
+func init() {
+	PollInterval = time.Millisecond
+}
+
+func TestWaitUntilChecksOncePerBlock(t *testing.T) {
+	heights := &fakeHeights{height: 10, every: 3}
+	checked := []uint64{}
+	err := WaitUntil(context.Background(), heights, func(context.Context) (bool, error) {
+		checked = append(checked, heights.height)
+		return len(checked) == 4, nil
+	}, 5)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{10, 11, 12, 13}, checked)
+}
+
+func TestWaitUntilGivesUp(t *testing.T) {
+	heights := &fakeHeights{height: 10, every: 1}
+	checks := 0
+	err := WaitUntil(context.Background(), heights, func(context.Context) (bool, error) {
+		checks++
+		return false, nil
+	}, 3)
+	require.EqualError(t, err, "condition did not hold after 3 blocks (heights 11 to 14)")
+	require.Equal(t, 4, checks)
+}
+
+func TestWaitUntilErrors(t *testing.T) {
+	err := WaitUntil(context.Background(), &fakeHeights{err: errors.New("node down")}, func(context.Context) (bool, error) {
+		return true, nil
+	}, 3)
+	require.EqualError(t, err, "querying height: node down")
+
+	err = WaitUntil(context.Background(), &fakeHeights{every: 1}, func(context.Context) (bool, error) {
+		return false, errors.New("bad query")
+	}, 3)
+	require.EqualError(t, err, "bad query")
+}
+
+func TestWaitUntilCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// the chain never makes a block.
+	err := WaitUntil(ctx, &fakeHeights{every: 1 << 30}, func(context.Context) (bool, error) {
+		return false, nil
+	}, 3)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCountReaches(t *testing.T) {
+	contract := &fakeContract{}
+	err := WaitUntil(context.Background(), &fakeHeights{every: 1}, CountReaches(contract, "juno1...", "channel-3", 3), 10)
+	require.NoError(t, err)
+	require.Equal(t, uint32(3), contract.count)
+	require.Equal(t, QueryMsg{GetCount: &GetCount{Channel: "channel-3"}}, contract.last)
+
+	contract = &fakeContract{}
+	err = WaitUntil(context.Background(), &fakeHeights{every: 1}, TimeoutCountReaches(contract, "juno1...", "channel-3", 2), 10)
+	require.NoError(t, err)
+	require.Equal(t, QueryMsg{GetTimeoutCount: &GetCount{Channel: "channel-3"}}, contract.last)
+}
+
+func TestClientStatusIs(t *testing.T) {
+	clients := &fakeClients{statuses: []string{"Active", "Active", "Expired"}}
+	err := WaitUntil(context.Background(), &fakeHeights{every: 1}, ClientStatusIs(clients, "07-tendermint-0", "Expired"), 10)
+	require.NoError(t, err)
+}
+
+func TestChannelFound(t *testing.T) {
+	relayer := &fakeRelayer{channels: readChannels(t, "channels.jsonl")}
+	var found ChannelPair
+	err := WaitUntil(context.Background(), &fakeHeights{every: 1}, ChannelFound(relayer, nil, "juno-1", left, right, &found), 10)
+	require.NoError(t, err)
+	require.Equal(t, 2, relayer.queries)
+	require.Equal(t, ChannelPair{Local: "channel-1", Remote: "channel-1"}, found)
+
+	err = WaitUntil(context.Background(), &fakeHeights{every: 1}, ChannelFound(relayer, nil, "juno-1", left, "transfer", &found), 3)
+	require.ErrorContains(t, err, "did not hold after 3 blocks")
+
+	// an ambiguous match is reported rather than waited out.
+	relayer = &fakeRelayer{channels: readChannels(t, "channels_ambiguous.jsonl")}
+	heights := &fakeHeights{every: 1}
+	err = WaitUntil(context.Background(), heights, ChannelFound(relayer, nil, "juno-1", left, right, &found), 10)
+	require.ErrorContains(t, err, "2 open channels between")
+	require.Equal(t, 2, relayer.queries)
+}
+
+func TestCommitmentsCleared(t *testing.T) {
+	node := &fakeNode{commitments: []string{
+		`{"commitments":[{"port_id":"wasm.juno1c","channel_id":"channel-1","sequence":"1","data":"AA=="}],"pagination":{}}`,
+		`{"commitments":[],"pagination":{}}`,
+	}}
+	cli := NewCLI(fakeChain{})
+	err := WaitUntil(context.Background(), &fakeHeights{every: 1}, CommitmentsCleared(node, cli, "wasm.juno1c", "channel-1"), 10)
+	require.NoError(t, err)
+	require.Equal(t, cli.IBCPacketCommitments("wasm.juno1c", "channel-1"), node.last)
+
+	node = &fakeNode{commitments: []string{"Error: not json"}}
+	err = WaitUntil(context.Background(), &fakeHeights{every: 1}, CommitmentsCleared(node, cli, "wasm.juno1c", "channel-1"), 10)
+	require.ErrorContains(t, err, "parsing packet commitments of channel-1")
+}
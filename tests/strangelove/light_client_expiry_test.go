@@ -148,24 +148,18 @@ func TestLightClientExpiry(t *testing.T) {
 	}
 
 	// Wait for the channel to get set up
-	err = testutil.WaitForBlocks(ctx, 10, left, right)
+	var channel helper.ChannelPair
+	err = helper.WaitUntil(ctx, left, helper.ChannelFound(relayer, erp, left.Config().ChainID, leftPort, rightPort, &channel), 10)
 	require.NoError(t, err)
-
-	channelInfo, err := relayer.GetChannels(ctx, erp, left.Config().ChainID)
-	if err != nil {
-		t.Fatal(err)
-	}
-	leftChannel := channelInfo[1].ChannelID
-	rightChannel := channelInfo[1].Counterparty.ChannelID
+	leftChannel, rightChannel := channel.Local, channel.Remote
 
 	_, err = leftCosmosChain.ExecuteContract(ctx, leftUser.KeyName, leftContract, "{\"increment\": { \"channel\":\""+leftChannel+"\"}}")
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	// wait a couple blocks for the incrementing to relay to the
-	// right chain.
-	err = testutil.WaitForBlocks(ctx, 10, left, right)
+	// wait for the incrementing to relay to the right chain.
+	err = helper.WaitUntil(ctx, right, helper.CountReaches(rightCosmosChain, rightContract, rightChannel, 1), 10)
 	require.NoError(t, err)
 
 	queryMsg := helper.QueryMsg{
@@ -178,12 +172,6 @@ func TestLightClientExpiry(t *testing.T) {
 	}
 	require.Equal(t, uint32(1), resp.Data.Count)
 
-	// Stop the relayer for the trusting period. This should cause
-	// the channel to expire as the light client should time out.
-	relayer.StopRelayer(ctx, erp)
-	time.Sleep(trustingPeriod)
-
-	// First, we check that the client is in the expired state.
 	grpcConn, err := grpc.Dial(
 		leftCosmosChain.GetHostGRPCAddress(),
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
@@ -196,15 +184,15 @@ func TestLightClientExpiry(t *testing.T) {
 			t.Logf("closing GRPC: %s", err)
 		}
 	})
-
 	clientClient := clienttypes.NewQueryClient(grpcConn)
-	statusResp, err := clientClient.ClientStatus(ctx, &clienttypes.QueryClientStatusRequest{
-		ClientId: "07-tendermint-0",
-	})
-	if err != nil {
-		t.Fatal("querying client state:", err)
-	}
-	require.Equal(t, "Expired", statusResp.Status)
+
+	// Stop the relayer for the trusting period. This should cause
+	// the channel to expire as the light client should time out.
+	// Blocks take around a second, so 100 blocks is plenty of
+	// time for the minute long trusting period to pass.
+	relayer.StopRelayer(ctx, erp)
+	err = helper.WaitUntil(ctx, left, helper.ClientStatusIs(clientClient, "07-tendermint-0", "Expired"), 100)
+	require.NoError(t, err)
 
 	// Having confirmed that the client is expired, we now check
 	// the connection. Interestingly, the connection remains open